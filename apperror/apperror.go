@@ -0,0 +1,77 @@
+package apperror
+
+import "fmt"
+
+// machine readable codes exposed in the graphql error extensions
+const (
+	CodeNotFound      = "NOT_FOUND"
+	CodeValidation    = "VALIDATION"
+	CodeForbidden     = "FORBIDDEN"
+	CodeInternal      = "INTERNAL"
+	CodeTimeout       = "TIMEOUT"
+	CodeUnavailable   = "SERVICE_UNAVAILABLE"
+	CodeReadOnly      = "READ_ONLY"
+	CodeDuplicateMlId = "DUPLICATE_ML_ID"
+
+	// set by the executor on errors graphql-go raises before any resolver runs
+	CodeParseFailed      = "GRAPHQL_PARSE_FAILED"
+	CodeValidationFailed = "GRAPHQL_VALIDATION_FAILED"
+
+	// transport level codes, raised before the request reaches the executor
+	CodeBadRequest       = "BAD_REQUEST"
+	CodePayloadTooLarge  = "PAYLOAD_TOO_LARGE"
+	CodeMethodNotAllowed = "METHOD_NOT_ALLOWED"
+	CodeUnsupportedMedia = "UNSUPPORTED_MEDIA_TYPE"
+	CodeRateLimited      = "RATE_LIMITED"
+	CodeUnauthenticated  = "UNAUTHENTICATED"
+)
+
+// ErrForbidden returned when the caller tries to touch another merchant's catalog
+var ErrForbidden = &Error{Code: CodeForbidden, Message: "forbidden: product belongs to another merchant"}
+
+// ErrUnauthenticated returned when an anonymous caller tries to change a merchant's catalog
+var ErrUnauthenticated = &Error{Code: CodeUnauthenticated, Message: "authentication required"}
+
+// ErrNotDeleted returned when restoring a product that is not soft deleted
+var ErrNotDeleted = &Error{Code: CodeNotFound, Message: "product not found or not deleted"}
+
+// ErrDuplicateMlId returned when another product already uses the ml_id
+var ErrDuplicateMlId = &Error{Code: CodeDuplicateMlId, Message: "a product with this mlId already exists"}
+
+// ErrInternal returned for any failure whose detail must stay server side
+var ErrInternal = &Error{Code: CodeInternal, Message: "internal server error"}
+
+// ErrTimeout returned when the request or query deadline passed
+var ErrTimeout = &Error{Code: CodeTimeout, Message: "request timed out"}
+
+// ErrUnavailable returned while the database circuit breaker is open
+var ErrUnavailable = &Error{Code: CodeUnavailable, Message: "service temporarily unavailable"}
+
+// ErrReadOnly returned for mutations while the service runs with READ_ONLY
+var ErrReadOnly = &Error{Code: CodeReadOnly, Message: "the service is read only, mutations are disabled"}
+
+// Error client facing error carrying a code in its extensions
+type Error struct {
+	Code    string
+	Message string
+	// Fields map an input field to what is wrong with it, exposed as extensions.fields
+	Fields map[string]string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Extensions implement gqlerrors.ExtendedError so the code ends up in the response
+func (e *Error) Extensions() map[string]interface{} {
+	extensions := map[string]interface{}{"code": e.Code}
+	if len(e.Fields) > 0 {
+		extensions["fields"] = e.Fields
+	}
+	return extensions
+}
+
+// Validationf create a VALIDATION error
+func Validationf(format string, args ...interface{}) *Error {
+	return &Error{Code: CodeValidation, Message: fmt.Sprintf(format, args...)}
+}
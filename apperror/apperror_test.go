@@ -0,0 +1,49 @@
+package apperror
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestErrorExtensions(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *Error
+		want map[string]interface{}
+	}{
+		{
+			name: "code only",
+			err:  ErrNotDeleted,
+			want: map[string]interface{}{"code": CodeNotFound},
+		},
+		{
+			name: "with fields",
+			err:  &Error{Code: CodeValidation, Message: "invalid input", Fields: map[string]string{"quota": "must not be negative"}},
+			want: map[string]interface{}{"code": CodeValidation, "fields": map[string]string{"quota": "must not be negative"}},
+		},
+		{
+			name: "empty fields are left out",
+			err:  &Error{Code: CodeValidation, Message: "invalid input", Fields: map[string]string{}},
+			want: map[string]interface{}{"code": CodeValidation},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Extensions(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Extensions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidationf(t *testing.T) {
+	err := Validationf("limit must be at least %d", 1)
+
+	if err.Code != CodeValidation {
+		t.Errorf("Code = %q, want %q", err.Code, CodeValidation)
+	}
+	if err.Error() != "limit must be at least 1" {
+		t.Errorf("Error() = %q, want %q", err.Error(), "limit must be at least 1")
+	}
+}
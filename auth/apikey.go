@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"strings"
+)
+
+type apiKey struct {
+	hash       [32]byte
+	merchantId string
+}
+
+// APIKeys static keys for server to server callers, each scoped to one merchant
+type APIKeys struct {
+	keys []apiKey
+}
+
+// ParseAPIKeys read a comma separated list of key:merchantId pairs, as found in the API_KEYS
+// env. Malformed entries are skipped.
+func ParseAPIKeys(value string) *APIKeys {
+	keys := &APIKeys{}
+	for _, entry := range strings.Split(value, ",") {
+		key, merchantId, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok || key == "" || merchantId == "" {
+			continue
+		}
+		keys.keys = append(keys.keys, apiKey{hash: sha256.Sum256([]byte(key)), merchantId: merchantId})
+	}
+
+	return keys
+}
+
+// Len return the number of configured keys
+func (k *APIKeys) Len() int {
+	return len(k.keys)
+}
+
+// Lookup return the merchant scoped to key. Keys are compared as sha256 digests in constant
+// time and every key is checked, so the timing tells nothing about which one matched.
+func (k *APIKeys) Lookup(key string) (string, bool) {
+	hash := sha256.Sum256([]byte(key))
+
+	merchantId := ""
+	found := false
+	for _, candidate := range k.keys {
+		if subtle.ConstantTimeCompare(hash[:], candidate.hash[:]) == 1 && !found {
+			merchantId = candidate.merchantId
+			found = true
+		}
+	}
+
+	return merchantId, found
+}
@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"context"
+	"os"
+	"test-sql/apperror"
+	"test-sql/dotenv"
+)
+
+type merchantKey struct{}
+
+// WithMerchant store the authenticated merchant id in the context
+func WithMerchant(ctx context.Context, merchantId string) context.Context {
+	return context.WithValue(ctx, merchantKey{}, merchantId)
+}
+
+// MerchantFromContext return the authenticated merchant id, false when the request is anonymous
+func MerchantFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+
+	merchantId, ok := ctx.Value(merchantKey{}).(string)
+	return merchantId, ok && merchantId != ""
+}
+
+// Authenticated make sure the request acts as a merchant. Anonymous requests are rejected
+// once API_KEYS configures a way to authenticate, a deployment without any keys keeps working
+// anonymously. AUTH_ALLOW_ANONYMOUS overrides that default either way.
+func Authenticated(ctx context.Context) error {
+	if _, ok := MerchantFromContext(ctx); ok {
+		return nil
+	}
+
+	configured := ParseAPIKeys(os.Getenv("API_KEYS")).Len() > 0
+	if dotenv.GetBool("AUTH_ALLOW_ANONYMOUS", !configured) {
+		return nil
+	}
+
+	return apperror.ErrUnauthenticated
+}
+
+// Authorize make sure the authenticated merchant owns merchantId, anonymous requests are
+// handled as in Authenticated
+func Authorize(ctx context.Context, merchantId string) error {
+	authMerchantId, ok := MerchantFromContext(ctx)
+	if !ok {
+		return Authenticated(ctx)
+	}
+
+	if authMerchantId != merchantId {
+		return apperror.ErrForbidden
+	}
+
+	return nil
+}
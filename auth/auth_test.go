@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"test-sql/apperror"
+	"testing"
+)
+
+func TestAuthorize(t *testing.T) {
+	tests := []struct {
+		name           string
+		ctx            context.Context
+		apiKeys        string
+		allowAnonymous string
+		merchantId     string
+		want           error
+	}{
+		{
+			name:       "owner",
+			ctx:        WithMerchant(context.Background(), "M001"),
+			merchantId: "M001",
+		},
+		{
+			name:       "other merchant",
+			ctx:        WithMerchant(context.Background(), "M002"),
+			merchantId: "M001",
+			want:       apperror.ErrForbidden,
+		},
+		{
+			name:       "anonymous without api keys configured",
+			ctx:        context.Background(),
+			merchantId: "M001",
+		},
+		{
+			name:       "anonymous with api keys configured",
+			ctx:        context.Background(),
+			apiKeys:    "key-one:M001",
+			merchantId: "M001",
+			want:       apperror.ErrUnauthenticated,
+		},
+		{
+			name:       "malformed api keys configure nothing",
+			ctx:        context.Background(),
+			apiKeys:    "malformed",
+			merchantId: "M001",
+		},
+		{
+			name:       "empty merchant is anonymous",
+			ctx:        WithMerchant(context.Background(), ""),
+			apiKeys:    "key-one:M001",
+			merchantId: "M001",
+			want:       apperror.ErrUnauthenticated,
+		},
+		{
+			name:           "anonymous allowed with api keys configured",
+			ctx:            context.Background(),
+			apiKeys:        "key-one:M001",
+			allowAnonymous: "true",
+			merchantId:     "M001",
+		},
+		{
+			name:           "anonymous rejected without api keys",
+			ctx:            context.Background(),
+			allowAnonymous: "false",
+			merchantId:     "M001",
+			want:           apperror.ErrUnauthenticated,
+		},
+		{
+			name:           "other merchant with anonymous allowed",
+			ctx:            WithMerchant(context.Background(), "M002"),
+			allowAnonymous: "true",
+			merchantId:     "M001",
+			want:           apperror.ErrForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("API_KEYS", tt.apiKeys)
+			t.Setenv("AUTH_ALLOW_ANONYMOUS", tt.allowAnonymous)
+
+			if err := Authorize(tt.ctx, tt.merchantId); !errors.Is(err, tt.want) {
+				t.Errorf("Authorize() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIKeysLookup(t *testing.T) {
+	keys := ParseAPIKeys("key-one:M001, key-two:M002,malformed,:M003,key-four:")
+
+	if keys.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", keys.Len())
+	}
+
+	tests := []struct {
+		key        string
+		merchantId string
+		found      bool
+	}{
+		{key: "key-one", merchantId: "M001", found: true},
+		{key: "key-two", merchantId: "M002", found: true},
+		{key: "key-three"},
+		{key: "malformed"},
+		{key: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			merchantId, found := keys.Lookup(tt.key)
+			if merchantId != tt.merchantId || found != tt.found {
+				t.Errorf("Lookup(%q) = %q, %t, want %q, %t", tt.key, merchantId, found, tt.merchantId, tt.found)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"test-sql/auth"
+)
+
+// audit actions recorded in product_audit_logs
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+)
+
+type AuditModel struct {
+	Id        sql.NullInt64
+	ProductId sql.NullInt64
+	Action    sql.NullString
+	Actor     sql.NullString
+	Before    sql.NullString
+	After     sql.NullString
+	CreatedAt sql.NullTime
+}
+
+type AuditEntity struct {
+	Id        int                    `json:"id"`
+	ProductId int                    `json:"productId"`
+	Action    *string                `json:"action"`
+	Actor     *string                `json:"actor"`
+	Before    map[string]interface{} `json:"before"`
+	After     map[string]interface{} `json:"after"`
+	CreatedAt *string                `json:"createdAt"`
+}
+
+// writeAudit record a product change inside the mutation transaction so both commit together.
+// The actor is the authenticated merchant, NULL for anonymous requests.
+func writeAudit(tx *sql.Tx, ctx context.Context, action string, productId int, before *ListEntity, after *ListEntity) error {
+	query := "INSERT INTO product_audit_logs (product_id, action, actor, before_snapshot, after_snapshot, created_at) VALUES (?, ?, ?, ?, ?, NOW())"
+
+	var actor sql.NullString
+	if merchantId, ok := auth.MerchantFromContext(ctx); ok {
+		actor = sql.NullString{String: merchantId, Valid: true}
+	}
+
+	beforeJSON, err := snapshot(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := snapshot(after)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, query, productId, action, actor, beforeJSON, afterJSON)
+	return err
+}
+
+// snapshot encode a product for the audit log, NULL when there is none
+func snapshot(product *ListEntity) (sql.NullString, error) {
+	if product == nil {
+		return sql.NullString{}, nil
+	}
+
+	encoded, err := json.Marshal(product)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(encoded), Valid: true}, nil
+}
+
+func fetchAuditLog(db *sql.DB, ctx context.Context, productId int) ([]*AuditEntity, error) {
+	timer := startQuery(ctx, "fetchAuditLog")
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	query := "SELECT id, product_id, action, actor, before_snapshot, after_snapshot, created_at from product_audit_logs where product_id = ? order by id ASC"
+
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, productId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*AuditEntity
+	for rows.Next() {
+		var data AuditModel
+		err = rows.Scan(
+			&data.Id,
+			&data.ProductId,
+			&data.Action,
+			&data.Actor,
+			&data.Before,
+			&data.After,
+			&data.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, &AuditEntity{
+			Id:        int(data.Id.Int64),
+			ProductId: int(data.ProductId.Int64),
+			Action:    nullToPtr(data.Action),
+			Actor:     nullToPtr(data.Actor),
+			Before:    nullToJSON(data.Before),
+			After:     nullToJSON(data.After),
+			CreatedAt: nullTimeToPtr(data.CreatedAt),
+		})
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	timer.done(len(entries), productId)
+	return entries, nil
+}
@@ -0,0 +1,115 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"syscall"
+	"test-sql/apperror"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// breaker states reported by Breaker.State
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half-open"
+)
+
+// Breaker stops sending queries to a database that keeps failing. After threshold consecutive
+// failures it opens and fails fast for cooldown, then lets a single probe through: success
+// closes it again, failure reopens it for another cooldown.
+type Breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	state     string
+	openedAt  time.Time
+}
+
+// NewBreaker create a closed breaker, a threshold below 1 disables it
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{threshold: threshold, cooldown: cooldown, state: BreakerClosed}
+}
+
+// State return closed, open or half-open
+func (b *Breaker) State() string {
+	if b == nil {
+		return BreakerClosed
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// allow return ErrUnavailable while the breaker is open, it turns half-open for one probe
+// once the cooldown passed
+func (b *Breaker) allow() error {
+	if b == nil || b.threshold < 1 {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return apperror.ErrUnavailable
+		}
+		b.state = BreakerHalfOpen
+		return nil
+	case BreakerHalfOpen:
+		// a probe is already in flight
+		return apperror.ErrUnavailable
+	default:
+		return nil
+	}
+}
+
+// record the outcome of a query let through by allow, only errors pointing at an unreachable
+// database count as failures
+func (b *Breaker) record(err error) {
+	if b == nil || b.threshold < 1 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !isUnavailable(err) {
+		b.failures = 0
+		b.state = BreakerClosed
+		return
+	}
+
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.state = BreakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// isUnavailable report whether err means the database could not serve the query at all, as
+// opposed to a query level error such as a missing row or a duplicate key
+func isUnavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, mysql.ErrInvalidConn) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.As(err, &netErr)
+}
@@ -0,0 +1,97 @@
+package db
+
+import "strings"
+
+// productColumns whitelist of selectable product fields mapped to their column, in select order
+var productColumns = []struct {
+	field  string
+	column string
+}{
+	{"id", "id"},
+	{"mlId", "ml_id"},
+	{"merchantId", "merchant_id"},
+	{"name", "name"},
+	{"longDesc", "long_desc"},
+	{"shortDesc", "short_desc"},
+	{"icon", "icon"},
+	{"quota", "quota"},
+	{"startPeriod", "start_period"},
+	{"endPeriod", "end_period"},
+	{"createdAt", "created_at"},
+	{"updatedAt", "updated_at"},
+	{"metadata", "metadata"},
+}
+
+// selectColumns pick the columns backing the requested fields, id is always selected and nil
+// fields means every column. Unknown fields are ignored.
+func selectColumns(fields []string) []string {
+	requested := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		requested[field] = true
+	}
+
+	columns := make([]string, 0, len(productColumns))
+	for _, c := range productColumns {
+		if fields == nil || c.field == "id" || requested[c.field] {
+			columns = append(columns, c.column)
+		}
+	}
+	return columns
+}
+
+// selectList render the columns for a SELECT clause
+func selectList(columns []string) string {
+	return strings.Join(columns, ", ")
+}
+
+// scanTargets return the ListModel fields to scan the columns into, in the same order
+func scanTargets(data *ListModel, columns []string) []interface{} {
+	targets := make([]interface{}, 0, len(columns))
+	for _, column := range columns {
+		switch column {
+		case "id":
+			targets = append(targets, &data.Id)
+		case "ml_id":
+			targets = append(targets, &data.MlId)
+		case "merchant_id":
+			targets = append(targets, &data.MerchantId)
+		case "name":
+			targets = append(targets, &data.Name)
+		case "long_desc":
+			targets = append(targets, &data.LongDesc)
+		case "short_desc":
+			targets = append(targets, &data.ShortDesc)
+		case "icon":
+			targets = append(targets, &data.Icon)
+		case "quota":
+			targets = append(targets, &data.Quota)
+		case "start_period":
+			targets = append(targets, &data.StartPeriod)
+		case "end_period":
+			targets = append(targets, &data.EndPeriod)
+		case "created_at":
+			targets = append(targets, &data.CreatedAt)
+		case "updated_at":
+			targets = append(targets, &data.UpdatedAt)
+		case "metadata":
+			targets = append(targets, &data.Metadata)
+		}
+	}
+	return targets
+}
+
+// knownFromInput report whether every field can be answered from the inserted values and the
+// new id, so a create can skip reading the row back. Timestamps are set by MySQL and need the
+// read, as does a nil fields which means every column.
+func knownFromInput(fields []string) bool {
+	if fields == nil {
+		return false
+	}
+
+	for _, field := range fields {
+		if field == "createdAt" || field == "updatedAt" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,65 @@
+package db
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestSelectColumns(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields []string
+		want   []string
+	}{
+		{name: "id and name", fields: []string{"id", "name"}, want: []string{"id", "name"}},
+		{name: "id is always selected", fields: []string{"name"}, want: []string{"id", "name"}},
+		{name: "select order kept", fields: []string{"metadata", "mlId"}, want: []string{"id", "ml_id", "metadata"}},
+		{name: "unknown fields ignored", fields: []string{"name", "merchant", "__typename"}, want: []string{"id", "name"}},
+		{name: "empty selection", fields: []string{}, want: []string{"id"}},
+		{name: "nil means every column", fields: nil, want: []string{"id", "ml_id", "merchant_id", "name", "long_desc", "short_desc", "icon", "quota", "start_period", "end_period", "created_at", "updated_at", "metadata"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := selectColumns(tt.fields); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selectColumns(%q) = %q, want %q", tt.fields, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchListSelectsRequestedColumns(t *testing.T) {
+	var query string
+	matcher := sqlmock.QueryMatcherFunc(func(_, actual string) error {
+		query = actual
+		return nil
+	})
+	conn, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(matcher))
+	if err != nil {
+		t.Fatalf("sqlmock.New() error = %v", err)
+	}
+	defer conn.Close()
+
+	mock.ExpectPrepare("").ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Promo"))
+
+	list, err := fetchList(conn, context.Background(), Params{Page: 1, Limit: 10, Fields: []string{"id", "name"}})
+	if err != nil {
+		t.Fatalf("fetchList() error = %v", err)
+	}
+	if len(list) != 1 || list[0].Id != 1 || *list[0].Name != "Promo" {
+		t.Errorf("fetchList() = %+v, want product 1 named Promo", list)
+	}
+
+	if !strings.HasPrefix(query, "SELECT id, name from products p") {
+		t.Errorf("query %q does not select only id and name", query)
+	}
+	for _, c := range productColumns {
+		if c.column != "id" && c.column != "name" && strings.Contains(query, c.column) {
+			t.Errorf("query %q selects the unrequested column %s", query, c.column)
+		}
+	}
+}
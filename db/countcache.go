@@ -0,0 +1,82 @@
+package db
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type countEntry struct {
+	total     int64
+	expiresAt time.Time
+}
+
+// countCache keep filtered totals for a short ttl so repeated pages skip the COUNT(*). It is
+// local to the process, other instances only see a write once their entries expire.
+type countCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]countEntry
+}
+
+func newCountCache(ttl time.Duration) *countCache {
+	return &countCache{ttl: ttl, entries: map[string]countEntry{}}
+}
+
+// countKey identify a count by the filters only, paging and sorting do not change the total
+func countKey(params Params) string {
+	format := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Format(PeriodLayout)
+	}
+	hasIcon := ""
+	if params.HasIcon != nil {
+		hasIcon = strconv.FormatBool(*params.HasIcon)
+	}
+
+	return fmt.Sprintf("%q|%q|%q|%s|%s|%s|%s|%t|%s|%d|%d", params.Search, params.FullText, params.MerchantId, format(params.ActiveOn), format(params.StartAfter), format(params.EndBefore), format(params.EndAfter), params.ActiveOnly, hasIcon, params.AfterId, params.BeforeId)
+}
+
+func (c *countCache) get(params Params) (int64, bool) {
+	if c.ttl <= 0 {
+		return 0, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[countKey(params)]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return 0, false
+	}
+	return entry.total, true
+}
+
+func (c *countCache) set(params Params, total int64) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// drop expired entries on write so filters that are never asked again do not pile up
+	now := time.Now()
+	for key, entry := range c.entries {
+		if now.After(entry.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+	c.entries[countKey(params)] = countEntry{total: total, expiresAt: now.Add(c.ttl)}
+}
+
+// invalidate forget every cached total, called after any write to products
+func (c *countCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = map[string]countEntry{}
+}
@@ -0,0 +1,128 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"test-sql/auth"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestCountCache(t *testing.T) {
+	tests := []struct {
+		name        string
+		ttl         time.Duration
+		second      Params
+		between     func(repo *productRepository)
+		wantQueries int
+	}{
+		{name: "same filters within the ttl", ttl: time.Minute, second: Params{MerchantId: "M001"}, wantQueries: 1},
+		{name: "paging does not change the key", ttl: time.Minute, second: Params{MerchantId: "M001", Page: 3, Limit: 10}, wantQueries: 1},
+		{name: "other filters", ttl: time.Minute, second: Params{MerchantId: "M002"}, wantQueries: 2},
+		{name: "cache disabled", ttl: 0, second: Params{MerchantId: "M001"}, wantQueries: 2},
+		{
+			name:        "expired",
+			ttl:         10 * time.Millisecond,
+			second:      Params{MerchantId: "M001"},
+			between:     func(*productRepository) { time.Sleep(20 * time.Millisecond) },
+			wantQueries: 2,
+		},
+		{
+			name:        "invalidated by a write",
+			ttl:         time.Minute,
+			second:      Params{MerchantId: "M001"},
+			between:     func(repo *productRepository) { repo.counts.invalidate() },
+			wantQueries: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, mock := newMock(t)
+			for i := 0; i < tt.wantQueries; i++ {
+				mock.ExpectPrepare("SELECT count\\(id\\) from products p").
+					ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(42))
+			}
+
+			repo := NewProductRepository(conn, nil, NewBreaker(5, time.Second)).(*productRepository)
+			repo.counts = newCountCache(tt.ttl)
+
+			if _, err := repo.Count(context.Background(), Params{MerchantId: "M001"}); err != nil {
+				t.Fatalf("first Count() error = %v", err)
+			}
+			if tt.between != nil {
+				tt.between(repo)
+			}
+
+			// the mock fails the test on a count query it does not expect
+			total, err := repo.Count(context.Background(), tt.second)
+			if err != nil {
+				t.Fatalf("second Count() error = %v", err)
+			}
+			if total != 42 {
+				t.Errorf("second Count() = %d, want 42", total)
+			}
+		})
+	}
+}
+
+func TestUpsertInvalidatesCounts(t *testing.T) {
+	tests := []struct {
+		name     string
+		affected int64
+	}{
+		{name: "insert", affected: 1},
+		{name: "update of an existing row", affected: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			columns := selectColumns(nil)
+			row := func(name string) *sqlmock.Rows {
+				values := make([]driver.Value, len(columns))
+				values[0], values[1], values[2], values[3] = 1, "ML-1", "M001", name
+				return sqlmock.NewRows(columns).AddRow(values...)
+			}
+
+			conn, mock := newMock(t)
+			mock.ExpectPrepare("SELECT count\\(id\\) from products p").
+				ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+			mock.ExpectBegin()
+			mock.ExpectPrepare("where p.ml_id = \\?").ExpectQuery().WillReturnRows(row("Promo"))
+			mock.ExpectPrepare("ON DUPLICATE KEY UPDATE").ExpectExec().WillReturnResult(sqlmock.NewResult(1, tt.affected))
+			mock.ExpectPrepare("where p.id = \\?").ExpectQuery().WillReturnRows(row("Sale"))
+			mock.ExpectExec("INSERT INTO product_audit_logs").WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectCommit()
+			mock.ExpectPrepare("SELECT count\\(id\\) from products p").
+				ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+			repo := NewProductRepository(conn, nil, NewBreaker(5, time.Second)).(*productRepository)
+			repo.counts = newCountCache(time.Minute)
+			ctx := auth.WithMerchant(context.Background(), "M001")
+			params := Params{Search: "Promo"}
+
+			if _, err := repo.Count(ctx, params); err != nil {
+				t.Fatalf("first Count() error = %v", err)
+			}
+			input := &ListModel{
+				MlId: sql.NullString{String: "ML-1", Valid: true},
+				Name: sql.NullString{String: "Sale", Valid: true},
+			}
+			if _, _, err := repo.Upsert(ctx, input); err != nil {
+				t.Fatalf("Upsert() error = %v", err)
+			}
+
+			// the renamed product no longer matches the search, the cached 1 would be stale
+			total, err := repo.Count(ctx, params)
+			if err != nil {
+				t.Fatalf("second Count() error = %v", err)
+			}
+			if total != 0 {
+				t.Errorf("second Count() = %d, want 0", total)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"test-sql/apperror"
+	"test-sql/dotenv"
+	"test-sql/logging"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Connect open the MySQL pool configured from the DB_* env. When DB_SOCKET is set the pool dials
+// that unix socket, as with the Cloud SQL proxy, and DB_HOST and DB_PORT are ignored.
+func Connect() (*sql.DB, error) {
+	if socket := os.Getenv("DB_SOCKET"); socket != "" {
+		return open("unix", socket, os.Getenv("DB_USER"), os.Getenv("DB_PASS"))
+	}
+
+	return open("tcp", tcpAddr(os.Getenv("DB_HOST"), os.Getenv("DB_PORT")), os.Getenv("DB_USER"), os.Getenv("DB_PASS"))
+}
+
+// ConnectReplica open the read replica pool from the DB_READ_* env, port and credentials fall
+// back to the primary ones. It returns a nil pool when DB_READ_HOST is not set.
+func ConnectReplica() (*sql.DB, error) {
+	host := os.Getenv("DB_READ_HOST")
+	if host == "" {
+		return nil, nil
+	}
+
+	return open(
+		"tcp",
+		tcpAddr(host, dotenv.GetString("DB_READ_PORT", os.Getenv("DB_PORT"))),
+		dotenv.GetString("DB_READ_USER", os.Getenv("DB_USER")),
+		dotenv.GetString("DB_READ_PASS", os.Getenv("DB_PASS")),
+	)
+}
+
+func tcpAddr(host string, port string) string {
+	return fmt.Sprintf("%s:%s", host, port)
+}
+
+// open create a pool dialing addr over net, "tcp" with host:port or "unix" with a socket path
+func open(net string, addr string, user string, password string) (*sql.DB, error) {
+	loc, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig, err := tlsConfigName()
+	if err != nil {
+		return nil, err
+	}
+
+	conn := mysql.Config{
+		User:                 user,
+		Passwd:               password,
+		DBName:               dotenv.GetString("DB_NAME", "wec_product"),
+		Addr:                 addr,
+		Net:                  net,
+		ParseTime:            true,
+		Loc:                  loc,
+		AllowNativePasswords: true,
+		Timeout:              60 * time.Second,
+		TLSConfig:            tlsConfig,
+	}
+
+	dsn := conn.FormatDSN()
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetConnMaxLifetime(10 * time.Minute)
+	db.SetConnMaxLifetime(10 * time.Minute)
+	db.SetMaxIdleConns(50)
+	db.SetMaxOpenConns(50)
+
+	return db, nil
+}
+
+// WaitForDatabase ping the database with exponential backoff so the app waits for MySQL
+// to come up instead of crash looping, attempts and delays are configurable via env
+func WaitForDatabase(ctx context.Context, db *sql.DB) error {
+	maxAttempts := dotenv.GetInt("DB_CONNECT_MAX_ATTEMPTS", 10)
+	pingTimeout := time.Duration(dotenv.GetInt("DB_CONNECT_TIMEOUT", 5)) * time.Second
+	maxBackoff := time.Duration(dotenv.GetInt("DB_CONNECT_MAX_BACKOFF", 30)) * time.Second
+	backoff := time.Second
+
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		pingCtx, cancel := context.WithTimeout(ctx, pingTimeout)
+		err = db.PingContext(pingCtx)
+		cancel()
+
+		if err == nil {
+			return nil
+		}
+
+		if attempt == maxAttempts {
+			break
+		}
+
+		slog.Warn("database not ready, retrying",
+			"attempt", attempt,
+			"max_attempts", maxAttempts,
+			"backoff", backoff,
+			"error", err,
+		)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, maxBackoff)
+	}
+
+	return fmt.Errorf("database unreachable after %d attempts: %w", maxAttempts, err)
+}
+
+// queryContext bound a single query by DB_QUERY_TIMEOUT seconds, falling back to the former
+// CONTEXT_TIMEOUT name and then 5. A shorter request deadline on the parent still wins, so the
+// effective timeout is min(request deadline, configured).
+func queryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, time.Duration(dotenv.GetInt("DB_QUERY_TIMEOUT", dotenv.GetInt("CONTEXT_TIMEOUT", 5)))*time.Second)
+}
+
+// queryFailed log a failed db call and return the error to hand to the caller. A deadline or a
+// cancellation becomes ErrTimeout so clients get TIMEOUT instead of the raw context error.
+func queryFailed(ctx context.Context, name string, start time.Time, err error) error {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		logging.QueryTimeout(ctx, name, start, err)
+		return apperror.ErrTimeout
+	}
+
+	logging.QueryError(ctx, name, err)
+	return err
+}
@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// newMock open a sqlmock database, its expectations are checked when the test ends
+func newMock(t testing.TB) (*sql.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New() error = %v", err)
+	}
+
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+		conn.Close()
+	})
+
+	return conn, mock
+}
+
+func TestQueryContextTimeout(t *testing.T) {
+	tests := []struct {
+		name           string
+		queryTimeout   string
+		contextTimeout string
+		want           time.Duration
+	}{
+		{name: "default", want: 5 * time.Second},
+		{name: "former name still honoured", contextTimeout: "7", want: 7 * time.Second},
+		{name: "new name", queryTimeout: "3", want: 3 * time.Second},
+		{name: "new name wins over the former", queryTimeout: "3", contextTimeout: "7", want: 3 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DB_QUERY_TIMEOUT", tt.queryTimeout)
+			t.Setenv("CONTEXT_TIMEOUT", tt.contextTimeout)
+
+			ctx, cancel := queryContext(context.Background())
+			defer cancel()
+
+			deadline, ok := ctx.Deadline()
+			if !ok {
+				t.Fatal("queryContext() has no deadline")
+			}
+			if remaining := time.Until(deadline); remaining > tt.want || remaining < tt.want-time.Second {
+				t.Errorf("deadline in %s, want %s", remaining, tt.want)
+			}
+		})
+	}
+}
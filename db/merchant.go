@@ -0,0 +1,121 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+func fetchMerchants(db *sql.DB, ctx context.Context, merchantIds []string) (map[string]*MerchantEntity, error) {
+	timer := startQuery(ctx, "fetchMerchants")
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	merchants := make(map[string]*MerchantEntity, len(merchantIds))
+	if len(merchantIds) == 0 {
+		return merchants, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(merchantIds)), ", ")
+	query := "SELECT id, merchant_id, name from merchants m where m.merchant_id IN (" + placeholders + ")"
+
+	args := make([]interface{}, 0, len(merchantIds))
+	for _, merchantId := range merchantIds {
+		args = append(args, merchantId)
+	}
+
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var data MerchantModel
+		err = rows.Scan(
+			&data.Id,
+			&data.MerchantId,
+			&data.Name,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		merchants[data.MerchantId.String] = &MerchantEntity{
+			Id:         int(data.Id.Int64),
+			MerchantId: nullToPtr(data.MerchantId),
+			Name:       nullToPtr(data.Name),
+		}
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	timer.done(len(merchants), args...)
+	return merchants, nil
+}
+
+// merchantCountFilter products counted per merchant, deleted and merchant-less rows are left out
+const merchantCountFilter = " from products p where p.deleted_at IS NULL and p.merchant_id IS NOT NULL and p.merchant_id <> ''"
+
+// fetchMerchantCounts page through the distinct merchant ids of products with their product
+// count, ordered by merchant id. The total of merchants comes from a window over the groups.
+func fetchMerchantCounts(db *sql.DB, ctx context.Context, page int, limit int) ([]*MerchantCountEntity, int64, error) {
+	timer := startQuery(ctx, "fetchMerchantCounts")
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	query := "SELECT p.merchant_id, COUNT(*), COUNT(*) OVER()" + merchantCountFilter + " GROUP BY p.merchant_id ORDER BY p.merchant_id limit ? offset ?"
+
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	list := []*MerchantCountEntity{}
+	var totalData int64
+	for rows.Next() {
+		var data MerchantCountEntity
+		if err = rows.Scan(&data.MerchantId, &data.ProductCount, &totalData); err != nil {
+			return nil, 0, err
+		}
+		list = append(list, &data)
+	}
+
+	if rows.Err() != nil {
+		return nil, 0, rows.Err()
+	}
+
+	timer.done(len(list), page, limit)
+	return list, totalData, nil
+}
+
+// fetchMerchantTotal count the distinct merchant ids of products
+func fetchMerchantTotal(db *sql.DB, ctx context.Context) (int64, error) {
+	timer := startQuery(ctx, "fetchMerchantTotal")
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	var totalData int64
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(DISTINCT p.merchant_id)"+merchantCountFilter).Scan(&totalData); err != nil {
+		return 0, err
+	}
+
+	timer.done(1)
+	return totalData, nil
+}
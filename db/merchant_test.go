@@ -0,0 +1,42 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestFetchMerchantsOneQuery(t *testing.T) {
+	tests := []struct {
+		name        string
+		merchantIds []string
+		wantQuery   string
+	}{
+		{name: "none", merchantIds: nil},
+		{name: "one", merchantIds: []string{"M001"}, wantQuery: `IN \(\?\)$`},
+		{name: "a page worth", merchantIds: []string{"M001", "M002", "M003"}, wantQuery: `IN \(\?, \?, \?\)$`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, mock := newMock(t)
+
+			rows := sqlmock.NewRows([]string{"id", "merchant_id", "name"})
+			for i, merchantId := range tt.merchantIds {
+				rows.AddRow(i+1, merchantId, "Merchant "+merchantId)
+			}
+			if tt.wantQuery != "" {
+				mock.ExpectPrepare(tt.wantQuery).ExpectQuery().WillReturnRows(rows)
+			}
+
+			merchants, err := fetchMerchants(conn, context.Background(), tt.merchantIds)
+			if err != nil {
+				t.Fatalf("fetchMerchants() error = %v", err)
+			}
+			if len(merchants) != len(tt.merchantIds) {
+				t.Errorf("%d merchants returned, want %d", len(merchants), len(tt.merchantIds))
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// PeriodLayout layout of the string typed start_period and end_period columns
+const PeriodLayout = "2006-01-02 15:04:05"
+
+type ListModel struct {
+	Id          sql.NullInt64
+	MlId        sql.NullString
+	MerchantId  sql.NullString
+	Name        sql.NullString
+	LongDesc    sql.NullString
+	ShortDesc   sql.NullString
+	Icon        sql.NullString
+	Quota       sql.NullString
+	StartPeriod sql.NullString
+	EndPeriod   sql.NullString
+	CreatedAt   sql.NullTime
+	UpdatedAt   sql.NullTime
+	Metadata    sql.NullString
+}
+
+type ListEntity struct {
+	Id          int                    `json:"id"`
+	MlId        *string                `json:"mlId"`
+	MerchantId  *string                `json:"merchantId"`
+	Name        *string                `json:"name"`
+	LongDesc    *string                `json:"longDesc"`
+	ShortDesc   *string                `json:"shortDesc"`
+	Icon        *string                `json:"icon"`
+	Quota       *int                   `json:"quota"`
+	StartPeriod *string                `json:"startPeriod"`
+	EndPeriod   *string                `json:"endPeriod"`
+	CreatedAt   *string                `json:"createdAt"`
+	UpdatedAt   *string                `json:"updatedAt"`
+	Metadata    map[string]interface{} `json:"metadata"`
+}
+
+type MerchantModel struct {
+	Id         sql.NullInt64
+	MerchantId sql.NullString
+	Name       sql.NullString
+}
+
+type MerchantEntity struct {
+	Id         int     `json:"id"`
+	MerchantId *string `json:"merchantId"`
+	Name       *string `json:"name"`
+}
+
+// MerchantCountEntity a merchant having products and how many, deleted products excluded
+type MerchantCountEntity struct {
+	MerchantId   string `json:"merchantId"`
+	ProductCount int    `json:"productCount"`
+}
+
+type Params struct {
+	Page       int
+	Limit      int
+	Search     string
+	FullText   string
+	MerchantId string
+	SortBy     string
+	SortOrder  string
+	ActiveOn   *time.Time
+	StartAfter *time.Time
+	EndBefore  *time.Time
+	EndAfter   *time.Time
+	ActiveOnly bool
+	// HasIcon keep only products with (true) or without (false) an icon, nil keeps both
+	HasIcon *bool
+	// AfterId and BeforeId keep only ids above or below a cursor, 0 when unset
+	AfterId  int
+	BeforeId int
+	// Fields limit the selected columns to the requested product fields, nil selects all
+	Fields []string
+}
+
+// nullToPtr convert sql.NullString into a string pointer, nil when the column is NULL
+func nullToPtr(ns sql.NullString) *string {
+	if !ns.Valid {
+		return nil
+	}
+
+	return &ns.String
+}
+
+// nullToJSON decode a JSON object column, nil when NULL or not an object
+func nullToJSON(ns sql.NullString) map[string]interface{} {
+	if !ns.Valid {
+		return nil
+	}
+
+	var value map[string]interface{}
+	if err := json.Unmarshal([]byte(ns.String), &value); err != nil {
+		return nil
+	}
+
+	return value
+}
+
+// nullToIntPtr convert a numeric sql.NullString into an int pointer, nil when NULL or not numeric
+func nullToIntPtr(ns sql.NullString) *int {
+	if !ns.Valid {
+		return nil
+	}
+
+	value, err := strconv.Atoi(ns.String)
+	if err != nil {
+		return nil
+	}
+
+	return &value
+}
+
+// nullTimeToPtr convert sql.NullTime into an ISO-8601 string pointer, nil when the column is NULL
+func nullTimeToPtr(nt sql.NullTime) *string {
+	if !nt.Valid {
+		return nil
+	}
+
+	formatted := nt.Time.Format(time.RFC3339)
+	return &formatted
+}
+
+// toEntity map a scanned row into the entity returned to graphql
+func toEntity(data *ListModel) *ListEntity {
+	return &ListEntity{
+		Id:          int(data.Id.Int64),
+		MlId:        nullToPtr(data.MlId),
+		MerchantId:  nullToPtr(data.MerchantId),
+		Name:        nullToPtr(data.Name),
+		LongDesc:    nullToPtr(data.LongDesc),
+		ShortDesc:   nullToPtr(data.ShortDesc),
+		Icon:        nullToPtr(data.Icon),
+		Quota:       nullToIntPtr(data.Quota),
+		StartPeriod: nullToPtr(data.StartPeriod),
+		EndPeriod:   nullToPtr(data.EndPeriod),
+		CreatedAt:   nullTimeToPtr(data.CreatedAt),
+		UpdatedAt:   nullTimeToPtr(data.UpdatedAt),
+		Metadata:    nullToJSON(data.Metadata),
+	}
+}
@@ -0,0 +1,778 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"test-sql/apperror"
+	"test-sql/auth"
+	"test-sql/dotenv"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// querier is satisfied by both *sql.DB and *sql.Tx so reads can join a transaction
+type querier interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// sortColumns whitelist of sortable fields mapped to their column
+var sortColumns = map[string]string{
+	"id":          "p.id",
+	"name":        "p.name",
+	"startPeriod": "p.start_period",
+	"endPeriod":   "p.end_period",
+}
+
+// sortOrders whitelist of allowed sort directions
+var sortOrders = map[string]string{
+	"ASC":  "ASC",
+	"DESC": "DESC",
+}
+
+func fetchList(db *sql.DB, ctx context.Context, params Params) ([]*ListEntity, error) {
+	timer := startQuery(ctx, "fetchList")
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	offset := (params.Page - 1) * params.Limit
+	orderBy, err := buildOrderBy(params)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := selectColumns(params.Fields)
+	where, args := buildFilter(params)
+	query := "SELECT " + selectList(columns) + " from products p" + where + orderBy + " limit ? offset ?"
+	args = append(args, params.Limit, offset)
+
+	var listModel []*ListModel
+	var list []*ListEntity
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return list, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return list, err
+	}
+	defer rows.Close()
+
+	if rows.Err() != nil {
+		return list, rows.Err()
+	}
+
+	for rows.Next() {
+		var data ListModel
+		err = rows.Scan(scanTargets(&data, columns)...)
+
+		if err != nil {
+			break
+		}
+
+		listModel = append(listModel, &data)
+	}
+
+	if err != nil {
+		return list, err
+	}
+
+	for _, item := range listModel {
+		list = append(list, toEntity(item))
+	}
+
+	timer.done(len(list), args...)
+	return list, nil
+}
+
+// fetchListWithTotal fetch a page together with the filtered total using a COUNT(*) OVER() window,
+// saving the separate count round trip. Requires MySQL 8.0+.
+func fetchListWithTotal(db *sql.DB, ctx context.Context, params Params) ([]*ListEntity, int64, error) {
+	timer := startQuery(ctx, "fetchListWithTotal")
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	offset := (params.Page - 1) * params.Limit
+	orderBy, err := buildOrderBy(params)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	columns := selectColumns(params.Fields)
+	where, args := buildFilter(params)
+	query := "SELECT " + selectList(columns) + ", COUNT(*) OVER() AS total_data from products p" + where + orderBy + " limit ? offset ?"
+	args = append(args, params.Limit, offset)
+
+	var listModel []*ListModel
+	var list []*ListEntity
+	var totalData int64
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return list, 0, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return list, 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var data ListModel
+		err = rows.Scan(append(scanTargets(&data, columns), &totalData)...)
+
+		if err != nil {
+			return list, 0, err
+		}
+
+		listModel = append(listModel, &data)
+	}
+
+	if rows.Err() != nil {
+		return list, 0, rows.Err()
+	}
+
+	for _, item := range listModel {
+		list = append(list, toEntity(item))
+	}
+
+	timer.done(len(list), args...)
+	return list, totalData, nil
+}
+
+func fetchTotalData(db *sql.DB, ctx context.Context, params Params) (int64, error) {
+	timer := startQuery(ctx, "fetchTotalData")
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	where, args := buildFilter(params)
+	query := "SELECT count(id) from products p" + where
+
+	var totalData int64
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return totalData, err
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRowContext(ctx, args...).Scan(&totalData)
+
+	if err != nil {
+		return totalData, err
+	}
+	timer.done(1, args...)
+	return totalData, nil
+}
+
+// buildFilter build the where clause and its arguments shared by list and count queries
+func buildFilter(params Params) (string, []interface{}) {
+	conditions := []string{"p.deleted_at IS NULL"}
+	var args []interface{}
+
+	if params.Search != "" {
+		conditions = append(conditions, "p.name LIKE ?")
+		args = append(args, "%"+escapeLike(params.Search)+"%")
+	}
+
+	// with DB_FULLTEXT the products_fulltext index answers the search, without it every column
+	// is scanned with LIKE which finds the same substrings, only slower
+	if params.FullText != "" {
+		if dotenv.GetBool("DB_FULLTEXT", false) {
+			conditions = append(conditions, "MATCH(p.name, p.short_desc, p.long_desc) AGAINST (? IN NATURAL LANGUAGE MODE)")
+			args = append(args, params.FullText)
+		} else {
+			pattern := "%" + escapeLike(params.FullText) + "%"
+			conditions = append(conditions, "(p.name LIKE ? OR p.short_desc LIKE ? OR p.long_desc LIKE ?)")
+			args = append(args, pattern, pattern, pattern)
+		}
+	}
+
+	if params.MerchantId != "" {
+		conditions = append(conditions, "p.merchant_id = ?")
+		args = append(args, params.MerchantId)
+	}
+
+	// period columns are strings in PeriodLayout, values are bound in the same layout and the
+	// columns cast so MySQL compares them as datetimes rather than lexically
+	if params.ActiveOn != nil {
+		conditions = append(conditions, "CAST(p.start_period AS DATETIME) < ?", "CAST(p.end_period AS DATETIME) >= ?")
+		args = append(args, params.ActiveOn.AddDate(0, 0, 1).Format(PeriodLayout), params.ActiveOn.Format(PeriodLayout))
+	}
+
+	if params.StartAfter != nil {
+		conditions = append(conditions, "CAST(p.start_period AS DATETIME) >= ?")
+		args = append(args, params.StartAfter.Format(PeriodLayout))
+	}
+
+	if params.EndBefore != nil {
+		conditions = append(conditions, "CAST(p.end_period AS DATETIME) <= ?")
+		args = append(args, params.EndBefore.Format(PeriodLayout))
+	}
+
+	if params.EndAfter != nil {
+		conditions = append(conditions, "CAST(p.end_period AS DATETIME) > ?")
+		args = append(args, params.EndAfter.Format(PeriodLayout))
+	}
+
+	if params.ActiveOnly {
+		conditions = append(conditions, "CAST(p.start_period AS DATETIME) <= NOW()", "CAST(p.end_period AS DATETIME) >= NOW()")
+	}
+
+	// only a NULL icon counts as missing, an empty string was set explicitly
+	if params.HasIcon != nil {
+		if *params.HasIcon {
+			conditions = append(conditions, "p.icon IS NOT NULL")
+		} else {
+			conditions = append(conditions, "p.icon IS NULL")
+		}
+	}
+
+	if params.AfterId > 0 {
+		conditions = append(conditions, "p.id > ?")
+		args = append(args, params.AfterId)
+	}
+
+	if params.BeforeId > 0 {
+		conditions = append(conditions, "p.id < ?")
+		args = append(args, params.BeforeId)
+	}
+
+	return " where " + strings.Join(conditions, " and "), args
+}
+
+// buildOrderBy build the order by clause from the sort whitelist, default to id ASC. Any other
+// column gets id as a tiebreaker so rows sharing a value keep a stable order across pages.
+func buildOrderBy(params Params) (string, error) {
+	sortBy := params.SortBy
+	if sortBy == "" {
+		sortBy = "id"
+	}
+
+	sortOrder := strings.ToUpper(params.SortOrder)
+	if sortOrder == "" {
+		sortOrder = "ASC"
+	}
+
+	column, ok := sortColumns[sortBy]
+	if !ok {
+		return "", apperror.Validationf("invalid sortBy value %q", params.SortBy)
+	}
+
+	direction, ok := sortOrders[sortOrder]
+	if !ok {
+		return "", apperror.Validationf("invalid sortOrder value %q", params.SortOrder)
+	}
+
+	if column == "p.id" {
+		return " order by p.id " + direction, nil
+	}
+	return " order by " + column + " " + direction + ", p.id " + direction, nil
+}
+
+// escapeLike escape LIKE wildcard characters so user input is matched literally
+func escapeLike(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+	return replacer.Replace(value)
+}
+
+// fetchOne select only the columns backing fields, every column when fields is nil
+func fetchOne(db querier, ctx context.Context, id int, fields ...string) (*ListEntity, error) {
+	timer := startQuery(ctx, "fetchOne")
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	columns := selectColumns(fields)
+	query := "SELECT " + selectList(columns) + " from products p where p.id = ? and p.deleted_at IS NULL limit 1"
+
+	var data ListModel
+
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	row := stmt.QueryRowContext(ctx, id)
+	err = row.Scan(scanTargets(&data, columns)...)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, sql.ErrNoRows
+		}
+
+		return nil, err
+	}
+
+	one := toEntity(&data)
+
+	timer.done(1, id)
+	return one, nil
+}
+
+// lockOne read a live product inside tx and lock its row until the transaction ends, so an
+// ownership check made on it still holds when the write runs
+func lockOne(tx *sql.Tx, ctx context.Context, id int) (*ListEntity, error) {
+	columns := selectColumns(nil)
+	query := "SELECT " + selectList(columns) + " from products p where p.id = ? and p.deleted_at IS NULL limit 1 FOR UPDATE"
+
+	var data ListModel
+	if err := tx.QueryRowContext(ctx, query, id).Scan(scanTargets(&data, columns)...); err != nil {
+		return nil, err
+	}
+
+	return toEntity(&data), nil
+}
+
+// fetchOneByMlId select only the columns backing fields, every column when fields is nil
+func fetchOneByMlId(db querier, ctx context.Context, mlId string, fields ...string) (*ListEntity, error) {
+	timer := startQuery(ctx, "fetchOneByMlId")
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	columns := selectColumns(fields)
+	query := "SELECT " + selectList(columns) + " from products p where p.ml_id = ? and p.deleted_at IS NULL limit 1"
+
+	var data ListModel
+
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	row := stmt.QueryRowContext(ctx, mlId)
+	err = row.Scan(scanTargets(&data, columns)...)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, sql.ErrNoRows
+		}
+
+		return nil, err
+	}
+
+	one := toEntity(&data)
+
+	timer.done(1, mlId)
+	return one, nil
+}
+
+// fetchByIds load the products with the given ids in one query, keyed by id. Missing or
+// deleted ids are simply absent from the map.
+func fetchByIds(db querier, ctx context.Context, ids []int, fields ...string) (map[int]*ListEntity, error) {
+	timer := startQuery(ctx, "fetchByIds")
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	products := make(map[int]*ListEntity, len(ids))
+	if len(ids) == 0 {
+		return products, nil
+	}
+
+	columns := selectColumns(fields)
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+	query := "SELECT " + selectList(columns) + " from products p where p.id IN (" + placeholders + ") and p.deleted_at IS NULL"
+
+	args := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var data ListModel
+		if err = rows.Scan(scanTargets(&data, columns)...); err != nil {
+			return nil, err
+		}
+
+		one := toEntity(&data)
+		products[one.Id] = one
+	}
+
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	timer.done(len(products), args...)
+	return products, nil
+}
+
+// createProduct insert the product and return it. When fields only asks for values known from
+// the input the row is not read back, see knownFromInput.
+func createProduct(db *sql.DB, ctx context.Context, input *ListModel, fields []string) (*ListEntity, error) {
+	timer := startQuery(ctx, "createProduct")
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	query := "INSERT INTO products (ml_id, merchant_id, name, long_desc, short_desc, icon, quota, start_period, end_period, metadata, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW(), NOW())"
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	// optional fields left out are invalid and stored as NULL
+	res, err := stmt.ExecContext(ctx,
+		input.MlId,
+		input.MerchantId,
+		input.Name,
+		input.LongDesc,
+		input.ShortDesc,
+		input.Icon,
+		input.Quota,
+		input.StartPeriod,
+		input.EndPeriod,
+		input.Metadata,
+	)
+
+	if err != nil {
+		if isDuplicateKey(err) {
+			return nil, apperror.ErrDuplicateMlId
+		}
+		if isNullViolation(err) {
+			return nil, apperror.Validationf("a field left out is required by the products table")
+		}
+		return nil, err
+	}
+
+	lastId, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	var one *ListEntity
+	if knownFromInput(fields) {
+		one = insertedEntity(lastId, input)
+	} else if one, err = fetchOne(tx, ctx, int(lastId)); err != nil {
+		return nil, err
+	}
+
+	if err = writeAudit(tx, ctx, AuditCreate, one.Id, nil, one); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	timer.done(1, input.MlId, input.MerchantId)
+	return one, nil
+}
+
+// upsertProduct insert the product or update the row holding the same ml_id. Fields left
+// NULL in input keep their stored value and merchant_id is only set on insert, so an upsert
+// never moves a product to another merchant. The bool reports whether a row was created.
+// A soft deleted product is not revived, the update is rolled back and sql.ErrNoRows returned.
+func upsertProduct(db *sql.DB, ctx context.Context, input *ListModel) (*ListEntity, bool, error) {
+	timer := startQuery(ctx, "upsertProduct")
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	// id = LAST_INSERT_ID(id) makes LastInsertId return the updated row too
+	query := "INSERT INTO products (ml_id, merchant_id, name, long_desc, short_desc, icon, quota, start_period, end_period, metadata, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW(), NOW()) " +
+		"ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id), " +
+		"name = COALESCE(VALUES(name), name), " +
+		"long_desc = COALESCE(VALUES(long_desc), long_desc), " +
+		"short_desc = COALESCE(VALUES(short_desc), short_desc), " +
+		"icon = COALESCE(VALUES(icon), icon), " +
+		"quota = COALESCE(VALUES(quota), quota), " +
+		"start_period = COALESCE(VALUES(start_period), start_period), " +
+		"end_period = COALESCE(VALUES(end_period), end_period), " +
+		"metadata = COALESCE(VALUES(metadata), metadata), " +
+		"updated_at = NOW()"
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	// snapshot the current row for the audit log, none when the upsert creates it
+	before, err := fetchOneByMlId(tx, ctx, input.MlId.String)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, false, err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, false, err
+	}
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx,
+		input.MlId,
+		input.MerchantId,
+		input.Name,
+		input.LongDesc,
+		input.ShortDesc,
+		input.Icon,
+		input.Quota,
+		input.StartPeriod,
+		input.EndPeriod,
+		input.Metadata,
+	)
+
+	if err != nil {
+		if isNullViolation(err) {
+			return nil, false, apperror.Validationf("merchantId and name are required when the product does not exist yet")
+		}
+		return nil, false, err
+	}
+
+	// MySQL reports 1 affected row for an insert and 2 for an update
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return nil, false, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, false, err
+	}
+
+	one, err := fetchOne(tx, ctx, int(id))
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err = authorizeOwner(ctx, one); err != nil {
+		return nil, false, err
+	}
+
+	action := AuditUpdate
+	if affected == 1 {
+		action = AuditCreate
+	}
+	if err = writeAudit(tx, ctx, action, one.Id, before, one); err != nil {
+		return nil, false, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, false, err
+	}
+
+	timer.done(1, input.MlId, input.MerchantId)
+	return one, affected == 1, nil
+}
+
+// insertedEntity build the created product from what createProduct stored
+func insertedEntity(id int64, input *ListModel) *ListEntity {
+	stored := *input
+	stored.Id = sql.NullInt64{Int64: id, Valid: true}
+	return toEntity(&stored)
+}
+
+// authorizeOwner make sure the caller may change product, one without a merchant only needs an
+// authenticated caller
+func authorizeOwner(ctx context.Context, product *ListEntity) error {
+	if product.MerchantId == nil {
+		return auth.Authenticated(ctx)
+	}
+
+	return auth.Authorize(ctx, *product.MerchantId)
+}
+
+// isDuplicateKey report whether err is a MySQL duplicate entry error (1062)
+func isDuplicateKey(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1062
+}
+
+// isNullViolation report whether err is a MySQL column cannot be null error (1048)
+func isNullViolation(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1048
+}
+
+// deleteProduct soft delete a product by stamping deleted_at, sql.ErrNoRows when it is already
+// gone. The row is locked while the owner is checked so the check cannot act on stale data.
+func deleteProduct(db *sql.DB, ctx context.Context, id int) error {
+	timer := startQuery(ctx, "deleteProduct")
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	query := "UPDATE products SET deleted_at = NOW() WHERE id = ? AND deleted_at IS NULL"
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := lockOne(tx, ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err = authorizeOwner(ctx, before); err != nil {
+		return err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	if err = writeAudit(tx, ctx, AuditDelete, id, before, nil); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	timer.done(int(affected), id)
+	return nil
+}
+
+// setProductQuota update only the quota of a live product and return it, sql.ErrNoRows when the
+// product does not exist. The owner is checked against the stored merchant, locked until the
+// update is written.
+func setProductQuota(db *sql.DB, ctx context.Context, id int, quota int) (*ListEntity, error) {
+	timer := startQuery(ctx, "setProductQuota")
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	query := "UPDATE products SET quota = ?, updated_at = NOW() WHERE id = ? AND deleted_at IS NULL"
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	before, err := lockOne(tx, ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = authorizeOwner(ctx, before); err != nil {
+		return nil, err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx, strconv.Itoa(quota), id)
+	if err != nil {
+		return nil, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	one, err := fetchOne(tx, ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = writeAudit(tx, ctx, AuditUpdate, id, before, one); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	timer.done(int(affected), id, quota)
+	return one, nil
+}
+
+// restoreProduct clear deleted_at of a soft deleted product and return it, the update and
+// read-back share a transaction so a forbidden restore is rolled back
+func restoreProduct(db *sql.DB, ctx context.Context, id int) (*ListEntity, error) {
+	timer := startQuery(ctx, "restoreProduct")
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	query := "UPDATE products SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL"
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	if affected == 0 {
+		return nil, apperror.ErrNotDeleted
+	}
+
+	one, err := fetchOne(tx, ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = authorizeOwner(ctx, one); err != nil {
+		return nil, err
+	}
+
+	if err = writeAudit(tx, ctx, AuditRestore, one.Id, nil, one); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	timer.done(int(affected), id)
+	return one, nil
+}
@@ -0,0 +1,333 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"test-sql/apperror"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestBuildFilter(t *testing.T) {
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local)
+	hasIcon, noIcon := true, false
+
+	tests := []struct {
+		name      string
+		params    Params
+		fullText  string
+		wantWhere string
+		wantArgs  []interface{}
+	}{
+		{
+			name:      "no filter keeps deleted rows out",
+			wantWhere: " where p.deleted_at IS NULL",
+		},
+		{
+			name:      "search escapes wildcards",
+			params:    Params{Search: "50%_off"},
+			wantWhere: " where p.deleted_at IS NULL and p.name LIKE ?",
+			wantArgs:  []interface{}{`%50\%\_off%`},
+		},
+		{
+			name:      "full text without index",
+			params:    Params{FullText: "promo"},
+			wantWhere: " where p.deleted_at IS NULL and (p.name LIKE ? OR p.short_desc LIKE ? OR p.long_desc LIKE ?)",
+			wantArgs:  []interface{}{"%promo%", "%promo%", "%promo%"},
+		},
+		{
+			name:      "full text with index",
+			params:    Params{FullText: "promo"},
+			fullText:  "true",
+			wantWhere: " where p.deleted_at IS NULL and MATCH(p.name, p.short_desc, p.long_desc) AGAINST (? IN NATURAL LANGUAGE MODE)",
+			wantArgs:  []interface{}{"promo"},
+		},
+		{
+			name:      "merchant and active on",
+			params:    Params{MerchantId: "M001", ActiveOn: &day},
+			wantWhere: " where p.deleted_at IS NULL and p.merchant_id = ? and CAST(p.start_period AS DATETIME) < ? and CAST(p.end_period AS DATETIME) >= ?",
+			wantArgs:  []interface{}{"M001", "2026-03-02 00:00:00", "2026-03-01 00:00:00"},
+		},
+		{
+			name:      "period bounds",
+			params:    Params{StartAfter: &day, EndBefore: &day, EndAfter: &day},
+			wantWhere: " where p.deleted_at IS NULL and CAST(p.start_period AS DATETIME) >= ? and CAST(p.end_period AS DATETIME) <= ? and CAST(p.end_period AS DATETIME) > ?",
+			wantArgs:  []interface{}{"2026-03-01 00:00:00", "2026-03-01 00:00:00", "2026-03-01 00:00:00"},
+		},
+		{
+			name:      "active only",
+			params:    Params{ActiveOnly: true},
+			wantWhere: " where p.deleted_at IS NULL and CAST(p.start_period AS DATETIME) <= NOW() and CAST(p.end_period AS DATETIME) >= NOW()",
+		},
+		{
+			name:      "has icon",
+			params:    Params{HasIcon: &hasIcon},
+			wantWhere: " where p.deleted_at IS NULL and p.icon IS NOT NULL",
+		},
+		{
+			name:      "without icon",
+			params:    Params{HasIcon: &noIcon},
+			wantWhere: " where p.deleted_at IS NULL and p.icon IS NULL",
+		},
+		{
+			name:      "cursor bounds",
+			params:    Params{AfterId: 10, BeforeId: 20},
+			wantWhere: " where p.deleted_at IS NULL and p.id > ? and p.id < ?",
+			wantArgs:  []interface{}{10, 20},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DB_FULLTEXT", tt.fullText)
+
+			where, args := buildFilter(tt.params)
+			if where != tt.wantWhere {
+				t.Errorf("where = %q\nwant    %q", where, tt.wantWhere)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestBuildOrderBy(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  Params
+		want    string
+		wantErr bool
+	}{
+		{name: "default", want: " order by p.id ASC"},
+		{name: "id descending", params: Params{SortBy: "id", SortOrder: "desc"}, want: " order by p.id DESC"},
+		{name: "name gets an id tiebreaker", params: Params{SortBy: "name"}, want: " order by p.name ASC, p.id ASC"},
+		{name: "end period descending", params: Params{SortBy: "endPeriod", SortOrder: "DESC"}, want: " order by p.end_period DESC, p.id DESC"},
+		{name: "unknown column", params: Params{SortBy: "quota; DROP TABLE products"}, wantErr: true},
+		{name: "unknown direction", params: Params{SortOrder: "sideways"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := buildOrderBy(tt.params)
+			if tt.wantErr {
+				var appErr *apperror.Error
+				if !errors.As(err, &appErr) || appErr.Code != apperror.CodeValidation {
+					t.Fatalf("buildOrderBy() error = %v, want a VALIDATION error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildOrderBy() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("buildOrderBy() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListQueriesCloseStatements(t *testing.T) {
+	const requests = 50
+
+	conn, mock := newMock(t)
+	for i := 0; i < requests; i++ {
+		mock.ExpectPrepare("SELECT id, name from products p").WillBeClosed().
+			ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Promo"))
+		mock.ExpectPrepare("SELECT count\\(id\\) from products p").WillBeClosed().
+			ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	}
+
+	params := Params{Page: 1, Limit: 10, Fields: []string{"name"}}
+	for i := 0; i < requests; i++ {
+		if _, err := fetchList(conn, context.Background(), params); err != nil {
+			t.Fatalf("fetchList() error = %v", err)
+		}
+		if _, err := fetchTotalData(conn, context.Background(), params); err != nil {
+			t.Fatalf("fetchTotalData() error = %v", err)
+		}
+	}
+
+	// every statement and connection went back to the pool, none is held by a leaked stmt
+	if stats := conn.Stats(); stats.InUse != 0 || stats.OpenConnections > 1 {
+		t.Errorf("%d connections open and %d in use after %d requests, want at most 1 and 0", stats.OpenConnections, stats.InUse, requests)
+	}
+}
+
+// BenchmarkListAndCount compare the separate list and count queries with the windowed one,
+// every query waits roundTrip so the benchmark measures the trips the way a remote MySQL would
+func BenchmarkListAndCount(b *testing.B) {
+	const roundTrip = time.Millisecond
+	params := Params{Page: 1, Limit: 10, Fields: []string{"name"}}
+
+	b.Run("separate", func(b *testing.B) {
+		conn, mock := newMock(b)
+		for i := 0; i < b.N; i++ {
+			mock.ExpectPrepare("SELECT id, name from products p").
+				ExpectQuery().WillDelayFor(roundTrip).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Promo"))
+			mock.ExpectPrepare("SELECT count\\(id\\) from products p").
+				ExpectQuery().WillDelayFor(roundTrip).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		}
+
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := fetchList(conn, context.Background(), params); err != nil {
+				b.Fatalf("fetchList() error = %v", err)
+			}
+			if _, err := fetchTotalData(conn, context.Background(), params); err != nil {
+				b.Fatalf("fetchTotalData() error = %v", err)
+			}
+		}
+	})
+
+	b.Run("combined", func(b *testing.B) {
+		conn, mock := newMock(b)
+		for i := 0; i < b.N; i++ {
+			mock.ExpectPrepare("SELECT id, name, COUNT\\(\\*\\) OVER\\(\\) AS total_data from products p").
+				ExpectQuery().WillDelayFor(roundTrip).WillReturnRows(sqlmock.NewRows([]string{"id", "name", "total_data"}).AddRow(1, "Promo", 1))
+		}
+
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, _, err := fetchListWithTotal(conn, context.Background(), params); err != nil {
+				b.Fatalf("fetchListWithTotal() error = %v", err)
+			}
+		}
+	})
+}
+
+func TestCreateProductDriverErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		driverErr   error
+		wantCode    string
+		wantMessage string
+	}{
+		{
+			name:        "duplicate ml_id",
+			driverErr:   &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'ML-1' for key 'products.ml_id'"},
+			wantCode:    apperror.CodeDuplicateMlId,
+			wantMessage: "a product with this mlId already exists",
+		},
+		{
+			name:        "column cannot be null",
+			driverErr:   &mysql.MySQLError{Number: 1048, Message: "Column 'name' cannot be null"},
+			wantCode:    apperror.CodeValidation,
+			wantMessage: "a field left out is required by the products table",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, mock := newMock(t)
+			mock.ExpectBegin()
+			mock.ExpectPrepare("INSERT INTO products").ExpectExec().WillReturnError(tt.driverErr)
+			mock.ExpectRollback()
+
+			products := NewProductRepository(conn, nil, NewBreaker(5, time.Second))
+			input := &ListModel{
+				MlId:       sql.NullString{String: "ML-1", Valid: true},
+				MerchantId: sql.NullString{String: "M001", Valid: true},
+				Name:       sql.NullString{String: "Promo", Valid: true},
+			}
+			_, err := products.Create(context.Background(), input)
+
+			var appErr *apperror.Error
+			if !errors.As(err, &appErr) {
+				t.Fatalf("Create() error = %v, want an apperror", err)
+			}
+			if appErr.Code != tt.wantCode || appErr.Message != tt.wantMessage {
+				t.Errorf("Create() error = %s %q, want %s %q", appErr.Code, appErr.Message, tt.wantCode, tt.wantMessage)
+			}
+		})
+	}
+}
+
+// sortRow the sortable columns of a row, rendered so that comparing the strings orders the values
+type sortRow map[string]string
+
+// orderRows sort rows the way MySQL applies orderBy. Rows are shuffled first since MySQL
+// returns rows tied on every ORDER BY column in no particular order, differently per query.
+func orderRows(t *testing.T, rows []sortRow, orderBy string, seed int64) []sortRow {
+	t.Helper()
+
+	ordered := append([]sortRow(nil), rows...)
+	rand.New(rand.NewSource(seed)).Shuffle(len(ordered), func(i, j int) { ordered[i], ordered[j] = ordered[j], ordered[i] })
+
+	terms := strings.Split(strings.TrimPrefix(orderBy, " order by "), ", ")
+	sort.Slice(ordered, func(i, j int) bool {
+		for _, term := range terms {
+			column, direction, _ := strings.Cut(term, " ")
+			a, ok := ordered[i][column]
+			if !ok {
+				t.Fatalf("order by unknown column %q", column)
+			}
+			b := ordered[j][column]
+			if a != b {
+				return (a < b) == (direction == "ASC")
+			}
+		}
+		return false
+	})
+	return ordered
+}
+
+func TestPagingVisitsEveryRowOnce(t *testing.T) {
+	const total, limit = 23, 5
+
+	// few distinct names and periods so most rows tie on the sort column
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	var rows []sortRow
+	for id := 1; id <= total; id++ {
+		rows = append(rows, sortRow{
+			"p.id":           fmt.Sprintf("%05d", id),
+			"p.name":         fmt.Sprintf("Product %d", id%3),
+			"p.start_period": day.Format(time.RFC3339),
+			"p.end_period":   day.AddDate(0, 0, id%4).Format(time.RFC3339),
+		})
+	}
+
+	tests := []struct {
+		name   string
+		params Params
+	}{
+		{name: "id", params: Params{}},
+		{name: "name ascending", params: Params{SortBy: "name", SortOrder: "ASC"}},
+		{name: "name descending", params: Params{SortBy: "name", SortOrder: "DESC"}},
+		{name: "every start period equal", params: Params{SortBy: "startPeriod"}},
+		{name: "end period descending", params: Params{SortBy: "endPeriod", SortOrder: "DESC"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orderBy, err := buildOrderBy(tt.params)
+			if err != nil {
+				t.Fatalf("buildOrderBy() error = %v", err)
+			}
+
+			seen := map[string]int{}
+			for page := 1; (page-1)*limit < total; page++ {
+				ordered := orderRows(t, rows, orderBy, int64(page))
+				offset := (page - 1) * limit
+				for _, row := range ordered[offset:min(offset+limit, total)] {
+					seen[row["p.id"]]++
+				}
+			}
+
+			for _, row := range rows {
+				if count := seen[row["p.id"]]; count != 1 {
+					t.Errorf("row %s seen on %d pages, want 1", row["p.id"], count)
+				}
+			}
+		})
+	}
+}
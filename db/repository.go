@@ -0,0 +1,347 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"test-sql/apperror"
+	"test-sql/dotenv"
+	"test-sql/tracing"
+	"time"
+)
+
+// ProductRepository abstract product persistence so resolvers can run against a fake in tests
+type ProductRepository interface {
+	List(ctx context.Context, params Params) ([]*ListEntity, error)
+	Count(ctx context.Context, params Params) (int64, error)
+	ListWithTotal(ctx context.Context, params Params) ([]*ListEntity, int64, error)
+	FindByID(ctx context.Context, id int, fields ...string) (*ListEntity, error)
+	FindByMlID(ctx context.Context, mlId string, fields ...string) (*ListEntity, error)
+	FindByIDs(ctx context.Context, ids []int, fields ...string) (map[int]*ListEntity, error)
+	Create(ctx context.Context, input *ListModel, fields ...string) (*ListEntity, error)
+	Upsert(ctx context.Context, input *ListModel) (*ListEntity, bool, error)
+	Delete(ctx context.Context, id int) error
+	Restore(ctx context.Context, id int) (*ListEntity, error)
+	SetQuota(ctx context.Context, id int, quota int) (*ListEntity, error)
+	AuditLog(ctx context.Context, productId int) ([]*AuditEntity, error)
+	MerchantCounts(ctx context.Context, page int, limit int) ([]*MerchantCountEntity, int64, error)
+}
+
+type productRepository struct {
+	primary *sql.DB
+	replica *sql.DB
+	breaker *Breaker
+	counts  *countCache
+}
+
+// NewProductRepository create a ProductRepository backed by MySQL. Reads go to the replica and
+// writes to the primary, a nil replica sends everything to the primary. Every call goes
+// through breaker. Filtered totals are cached for COUNT_CACHE_TTL seconds (default 5, 0
+// disables) and dropped on every write.
+func NewProductRepository(primary *sql.DB, replica *sql.DB, breaker *Breaker) ProductRepository {
+	if replica == nil {
+		replica = primary
+	}
+
+	return &productRepository{
+		primary: primary,
+		replica: replica,
+		breaker: breaker,
+		counts:  newCountCache(time.Duration(dotenv.GetInt("COUNT_CACHE_TTL", 5)) * time.Second),
+	}
+}
+
+func (r *productRepository) List(ctx context.Context, params Params) ([]*ListEntity, error) {
+	ctx, span := tracing.StartSpan(ctx, "fetchList")
+	defer span.End()
+	start := time.Now()
+
+	list, err := retryRead(ctx, r.breaker, "fetchList", func() ([]*ListEntity, error) {
+		return fetchList(r.replica, ctx, params)
+	})
+	if err != nil {
+		err = queryFailed(ctx, "fetchList", start, err)
+	}
+	return list, err
+}
+
+func (r *productRepository) Count(ctx context.Context, params Params) (int64, error) {
+	if total, ok := r.counts.get(params); ok {
+		return total, nil
+	}
+
+	ctx, span := tracing.StartSpan(ctx, "fetchTotalData")
+	defer span.End()
+	start := time.Now()
+
+	total, err := retryRead(ctx, r.breaker, "fetchTotalData", func() (int64, error) {
+		return fetchTotalData(r.replica, ctx, params)
+	})
+	if err != nil {
+		err = queryFailed(ctx, "fetchTotalData", start, err)
+		return total, err
+	}
+
+	r.counts.set(params, total)
+	return total, nil
+}
+
+// ListWithTotal fetch a page and the filtered total. The first page gets both in one query.
+// Later pages read the (cached) total first and come back empty without a query once the offset
+// is past it, so a huge page number never makes MySQL scan and discard rows.
+func (r *productRepository) ListWithTotal(ctx context.Context, params Params) ([]*ListEntity, int64, error) {
+	if params.Page > 1 {
+		total, err := r.Count(ctx, params)
+		if err != nil {
+			return nil, total, err
+		}
+
+		if offset := int64(params.Page-1) * int64(params.Limit); offset >= total {
+			return []*ListEntity{}, total, nil
+		}
+
+		list, err := r.List(ctx, params)
+		return list, total, err
+	}
+
+	ctx, span := tracing.StartSpan(ctx, "fetchListWithTotal")
+	defer span.End()
+	start := time.Now()
+
+	var total int64
+	list, err := retryRead(ctx, r.breaker, "fetchListWithTotal", func() ([]*ListEntity, error) {
+		list, count, err := fetchListWithTotal(r.replica, ctx, params)
+		total = count
+		return list, err
+	})
+	if err != nil {
+		err = queryFailed(ctx, "fetchListWithTotal", start, err)
+		return list, total, err
+	}
+
+	r.counts.set(params, total)
+	return list, total, nil
+}
+
+func (r *productRepository) FindByID(ctx context.Context, id int, fields ...string) (*ListEntity, error) {
+	ctx, span := tracing.StartSpan(ctx, "fetchOne")
+	defer span.End()
+	start := time.Now()
+
+	one, err := retryRead(ctx, r.breaker, "fetchOne", func() (*ListEntity, error) {
+		return fetchOne(r.replica, ctx, id, fields...)
+	})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		err = queryFailed(ctx, "fetchOne", start, err)
+	}
+	return one, err
+}
+
+func (r *productRepository) FindByMlID(ctx context.Context, mlId string, fields ...string) (*ListEntity, error) {
+	ctx, span := tracing.StartSpan(ctx, "fetchOneByMlId")
+	defer span.End()
+	start := time.Now()
+
+	one, err := retryRead(ctx, r.breaker, "fetchOneByMlId", func() (*ListEntity, error) {
+		return fetchOneByMlId(r.replica, ctx, mlId, fields...)
+	})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		err = queryFailed(ctx, "fetchOneByMlId", start, err)
+	}
+	return one, err
+}
+
+func (r *productRepository) FindByIDs(ctx context.Context, ids []int, fields ...string) (map[int]*ListEntity, error) {
+	ctx, span := tracing.StartSpan(ctx, "fetchByIds")
+	defer span.End()
+	start := time.Now()
+
+	products, err := retryRead(ctx, r.breaker, "fetchByIds", func() (map[int]*ListEntity, error) {
+		return fetchByIds(r.replica, ctx, ids, fields...)
+	})
+	if err != nil {
+		err = queryFailed(ctx, "fetchByIds", start, err)
+	}
+	return products, err
+}
+
+// Create insert the product, fields are the ones the caller will read from the result and let
+// it skip reading the row back, see knownFromInput
+func (r *productRepository) Create(ctx context.Context, input *ListModel, fields ...string) (*ListEntity, error) {
+	ctx, span := tracing.StartSpan(ctx, "createProduct")
+	defer span.End()
+	start := time.Now()
+
+	if err := r.breaker.allow(); err != nil {
+		return nil, err
+	}
+
+	one, err := createProduct(r.primary, ctx, input, fields)
+	r.breaker.record(err)
+	var gqlErr *apperror.Error
+	if err != nil && !errors.As(err, &gqlErr) {
+		err = queryFailed(ctx, "createProduct", start, err)
+	}
+	if err == nil {
+		r.counts.invalidate()
+	}
+	return one, err
+}
+
+func (r *productRepository) Upsert(ctx context.Context, input *ListModel) (*ListEntity, bool, error) {
+	ctx, span := tracing.StartSpan(ctx, "upsertProduct")
+	defer span.End()
+	start := time.Now()
+
+	if err := r.breaker.allow(); err != nil {
+		return nil, false, err
+	}
+
+	one, created, err := upsertProduct(r.primary, ctx, input)
+	r.breaker.record(err)
+	var gqlErr *apperror.Error
+	if err != nil && !errors.As(err, &gqlErr) && !errors.Is(err, sql.ErrNoRows) {
+		err = queryFailed(ctx, "upsertProduct", start, err)
+	}
+	if err == nil {
+		r.counts.invalidate()
+	}
+	return one, created, err
+}
+
+func (r *productRepository) Delete(ctx context.Context, id int) error {
+	ctx, span := tracing.StartSpan(ctx, "deleteProduct")
+	defer span.End()
+	start := time.Now()
+
+	if err := r.breaker.allow(); err != nil {
+		return err
+	}
+
+	err := deleteProduct(r.primary, ctx, id)
+	r.breaker.record(err)
+	var gqlErr *apperror.Error
+	if err != nil && !errors.As(err, &gqlErr) && !errors.Is(err, sql.ErrNoRows) {
+		err = queryFailed(ctx, "deleteProduct", start, err)
+	}
+	if err == nil {
+		r.counts.invalidate()
+	}
+	return err
+}
+
+func (r *productRepository) Restore(ctx context.Context, id int) (*ListEntity, error) {
+	ctx, span := tracing.StartSpan(ctx, "restoreProduct")
+	defer span.End()
+	start := time.Now()
+
+	if err := r.breaker.allow(); err != nil {
+		return nil, err
+	}
+
+	one, err := restoreProduct(r.primary, ctx, id)
+	r.breaker.record(err)
+	var gqlErr *apperror.Error
+	if err != nil && !errors.As(err, &gqlErr) {
+		err = queryFailed(ctx, "restoreProduct", start, err)
+	}
+	if err == nil {
+		r.counts.invalidate()
+	}
+	return one, err
+}
+
+// SetQuota update only the quota on the primary, the breaker and error mapping are the same
+// as the other writes
+func (r *productRepository) SetQuota(ctx context.Context, id int, quota int) (*ListEntity, error) {
+	ctx, span := tracing.StartSpan(ctx, "setProductQuota")
+	defer span.End()
+	start := time.Now()
+
+	if err := r.breaker.allow(); err != nil {
+		return nil, err
+	}
+
+	one, err := setProductQuota(r.primary, ctx, id, quota)
+	r.breaker.record(err)
+	var gqlErr *apperror.Error
+	if err != nil && !errors.As(err, &gqlErr) && !errors.Is(err, sql.ErrNoRows) {
+		err = queryFailed(ctx, "setProductQuota", start, err)
+	}
+	return one, err
+}
+
+// AuditLog read the change history of a product from the primary, so a change is visible
+// right after its mutation even when the replica lags
+func (r *productRepository) AuditLog(ctx context.Context, productId int) ([]*AuditEntity, error) {
+	ctx, span := tracing.StartSpan(ctx, "fetchAuditLog")
+	defer span.End()
+	start := time.Now()
+
+	entries, err := retryRead(ctx, r.breaker, "fetchAuditLog", func() ([]*AuditEntity, error) {
+		return fetchAuditLog(r.primary, ctx, productId)
+	})
+	if err != nil {
+		err = queryFailed(ctx, "fetchAuditLog", start, err)
+	}
+	return entries, err
+}
+
+// MerchantCounts page through the merchants having products with their product count. A page
+// past the end has no row carrying the total, so it is counted separately in that case.
+func (r *productRepository) MerchantCounts(ctx context.Context, page int, limit int) ([]*MerchantCountEntity, int64, error) {
+	ctx, span := tracing.StartSpan(ctx, "fetchMerchantCounts")
+	defer span.End()
+	start := time.Now()
+
+	var total int64
+	list, err := retryRead(ctx, r.breaker, "fetchMerchantCounts", func() ([]*MerchantCountEntity, error) {
+		list, count, err := fetchMerchantCounts(r.replica, ctx, page, limit)
+		total = count
+		return list, err
+	})
+	if err != nil {
+		err = queryFailed(ctx, "fetchMerchantCounts", start, err)
+		return nil, 0, err
+	}
+
+	if len(list) == 0 && page > 1 {
+		total, err = retryRead(ctx, r.breaker, "fetchMerchantTotal", func() (int64, error) {
+			return fetchMerchantTotal(r.replica, ctx)
+		})
+		if err != nil {
+			err = queryFailed(ctx, "fetchMerchantTotal", start, err)
+		}
+	}
+	return list, total, err
+}
+
+// MerchantRepository load merchants for the nested merchant resolver
+type MerchantRepository interface {
+	FindByMerchantIds(ctx context.Context, merchantIds []string) (map[string]*MerchantEntity, error)
+}
+
+type merchantRepository struct {
+	db      *sql.DB
+	breaker *Breaker
+}
+
+// NewMerchantRepository create a MerchantRepository backed by MySQL, pass the replica when
+// there is one since it only reads
+func NewMerchantRepository(db *sql.DB, breaker *Breaker) MerchantRepository {
+	return &merchantRepository{db: db, breaker: breaker}
+}
+
+func (r *merchantRepository) FindByMerchantIds(ctx context.Context, merchantIds []string) (map[string]*MerchantEntity, error) {
+	ctx, span := tracing.StartSpan(ctx, "fetchMerchants")
+	defer span.End()
+	start := time.Now()
+
+	merchants, err := retryRead(ctx, r.breaker, "fetchMerchants", func() (map[string]*MerchantEntity, error) {
+		return fetchMerchants(r.db, ctx, merchantIds)
+	})
+	if err != nil {
+		err = queryFailed(ctx, "fetchMerchants", start, err)
+	}
+	return merchants, err
+}
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"syscall"
+	"test-sql/dotenv"
+	"test-sql/logging"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// retryRead run an idempotent read again when it fails with a transient error, up to
+// DB_READ_RETRIES extra attempts (default 2) with a backoff doubling from DB_READ_RETRY_BACKOFF
+// milliseconds (default 50). Writes must never go through here, a retried insert could
+// apply twice. The breaker sees the outcome of all attempts as one call.
+func retryRead[T any](ctx context.Context, breaker *Breaker, name string, read func() (T, error)) (T, error) {
+	if err := breaker.allow(); err != nil {
+		var zero T
+		return zero, err
+	}
+
+	retries := dotenv.GetInt("DB_READ_RETRIES", 2)
+	backoff := time.Duration(dotenv.GetInt("DB_READ_RETRY_BACKOFF", 50)) * time.Millisecond
+
+	result, err := read()
+	for attempt := 1; attempt <= retries && isTransient(err); attempt++ {
+		logging.FromContext(ctx).WarnContext(ctx, "transient query error, retrying",
+			"query", name,
+			"attempt", attempt,
+			"backoff", backoff,
+			"error", err,
+		)
+
+		select {
+		case <-ctx.Done():
+			breaker.record(err)
+			return result, err
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		result, err = read()
+	}
+
+	breaker.record(err)
+	return result, err
+}
+
+// isTransient report whether err is worth retrying: deadlock (1213), lock wait timeout (1205)
+// or a connection dropped under the query
+func isTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == 1213 || mysqlErr.Number == 1205
+	}
+
+	return errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, mysql.ErrInvalidConn) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET)
+}
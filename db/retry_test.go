@@ -0,0 +1,57 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestRetryRead(t *testing.T) {
+	deadlock := &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}
+	lockWait := &mysql.MySQLError{Number: 1205, Message: "Lock wait timeout exceeded"}
+	syntax := &mysql.MySQLError{Number: 1064, Message: "You have an error in your SQL syntax"}
+
+	tests := []struct {
+		name      string
+		retries   string
+		failures  []error
+		wantCalls int
+		wantErr   error
+	}{
+		{name: "fails twice then succeeds", failures: []error{deadlock, lockWait}, wantCalls: 3},
+		{name: "dropped connection", failures: []error{mysql.ErrInvalidConn}, wantCalls: 2},
+		{name: "out of retries", failures: []error{deadlock, deadlock, deadlock}, wantCalls: 3, wantErr: deadlock},
+		{name: "retries disabled", retries: "0", failures: []error{deadlock}, wantCalls: 1, wantErr: deadlock},
+		{name: "not transient", failures: []error{syntax}, wantCalls: 1, wantErr: syntax},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DB_READ_RETRIES", tt.retries)
+			t.Setenv("DB_READ_RETRY_BACKOFF", "1")
+
+			calls := 0
+			read := func() (int, error) {
+				calls++
+				if calls <= len(tt.failures) {
+					return 0, tt.failures[calls-1]
+				}
+				return 42, nil
+			}
+
+			got, err := retryRead(context.Background(), NewBreaker(5, time.Second), "stub", read)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("retryRead() error = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("read called %d times, want %d", calls, tt.wantCalls)
+			}
+			if tt.wantErr == nil && got != 42 {
+				t.Errorf("retryRead() = %d, want 42", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package db
+
+import (
+	"context"
+	"test-sql/dotenv"
+	"test-sql/logging"
+	"time"
+)
+
+// queryTimer time one db call from startQuery to done
+type queryTimer struct {
+	ctx   context.Context
+	name  string
+	start time.Time
+}
+
+// startQuery start timing the named db call
+func startQuery(ctx context.Context, name string) queryTimer {
+	return queryTimer{ctx: ctx, name: name, start: time.Now()}
+}
+
+// done log the finished call with its row count. A call that took longer than
+// DB_SLOW_QUERY_MS milliseconds (default 1000, 0 disables) is also logged at warn level with
+// args, the values it was called with.
+func (t queryTimer) done(rows int, args ...interface{}) {
+	logging.Query(t.ctx, t.name, t.start, rows)
+
+	threshold := time.Duration(dotenv.GetInt("DB_SLOW_QUERY_MS", 1000)) * time.Millisecond
+	if elapsed := time.Since(t.start); threshold > 0 && elapsed >= threshold {
+		logging.SlowQuery(t.ctx, t.name, elapsed, args)
+	}
+}
@@ -0,0 +1,79 @@
+package db
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+var registerTLSOnce sync.Once
+var registerTLSErr error
+
+// tlsConfigName resolve DB_TLS into the value of mysql.Config.TLSConfig:
+//
+//   - disable (default): plain connection, as before
+//   - require: encrypted but the server certificate is not checked
+//   - verify-ca: the server certificate must chain to the CA bundle in DB_TLS_CA, the host name
+//     is not checked so it also works through proxies and ip addresses
+//
+// DB_TLS_CA is the path to a PEM file holding the CA certificates, for instance the bundle
+// your cloud provider publishes for its managed MySQL.
+func tlsConfigName() (string, error) {
+	switch mode := strings.ToLower(os.Getenv("DB_TLS")); mode {
+	case "", "disable":
+		return "", nil
+	case "require":
+		return "skip-verify", nil
+	case "verify-ca":
+		registerTLSOnce.Do(func() {
+			registerTLSErr = registerVerifyCA(os.Getenv("DB_TLS_CA"))
+		})
+		return "verify-ca", registerTLSErr
+	default:
+		return "", fmt.Errorf("unknown DB_TLS mode %q, expected disable, require or verify-ca", mode)
+	}
+}
+
+func registerVerifyCA(caPath string) error {
+	if caPath == "" {
+		return errors.New("DB_TLS=verify-ca requires DB_TLS_CA")
+	}
+
+	pem, err := os.ReadFile(caPath)
+	if err != nil {
+		return fmt.Errorf("read DB_TLS_CA: %w", err)
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(pem) {
+		return fmt.Errorf("DB_TLS_CA %s holds no PEM certificate", caPath)
+	}
+
+	return mysql.RegisterTLSConfig("verify-ca", &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// the default verification also checks the host name, verify the chain only instead
+		InsecureSkipVerify: true,
+		VerifyConnection: func(state tls.ConnectionState) error {
+			if len(state.PeerCertificates) == 0 {
+				return errors.New("mysql server sent no certificate")
+			}
+
+			intermediates := x509.NewCertPool()
+			for _, cert := range state.PeerCertificates[1:] {
+				intermediates.AddCert(cert)
+			}
+
+			_, err := state.PeerCertificates[0].Verify(x509.VerifyOptions{
+				Roots:         roots,
+				Intermediates: intermediates,
+			})
+			return err
+		},
+	})
+}
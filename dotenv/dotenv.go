@@ -40,3 +40,15 @@ func GetBool(variable string, fallback bool) bool {
 
 	return fallback
 }
+
+// Missing return the variables that are not set or empty
+func Missing(variables ...string) []string {
+	var missing []string
+	for _, variable := range variables {
+		if os.Getenv(variable) == "" {
+			missing = append(missing, variable)
+		}
+	}
+
+	return missing
+}
@@ -0,0 +1,64 @@
+package dotenv
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetInt(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  int
+	}{
+		{name: "unset", value: "", want: 7},
+		{name: "number", value: "42", want: 42},
+		{name: "zero", value: "0", want: 0},
+		{name: "negative", value: "-1", want: -1},
+		{name: "not a number", value: "ten", want: 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DOTENV_TEST_INT", tt.value)
+
+			if got := GetInt("DOTENV_TEST_INT", 7); got != tt.want {
+				t.Errorf("GetInt() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetBool(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  bool
+	}{
+		{name: "unset", value: "", want: true},
+		{name: "false", value: "false", want: false},
+		{name: "zero", value: "0", want: false},
+		{name: "not a bool", value: "nope", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DOTENV_TEST_BOOL", tt.value)
+
+			if got := GetBool("DOTENV_TEST_BOOL", true); got != tt.want {
+				t.Errorf("GetBool() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMissing(t *testing.T) {
+	t.Setenv("DOTENV_TEST_SET", "value")
+	t.Setenv("DOTENV_TEST_EMPTY", "")
+
+	got := Missing("DOTENV_TEST_SET", "DOTENV_TEST_EMPTY", "DOTENV_TEST_UNSET")
+	want := []string{"DOTENV_TEST_EMPTY", "DOTENV_TEST_UNSET"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Missing() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,270 @@
+package graph
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"test-sql/apperror"
+	"test-sql/db"
+	"test-sql/logging"
+	"time"
+
+	"github.com/graphql-go/graphql"
+)
+
+// maxIds cap the ids productsByIds accepts in one call
+const maxIds = 100
+
+// parseDateArg read an optional date argument given as YYYY-MM-DD or in db.PeriodLayout
+func parseDateArg(args map[string]interface{}, name string) (*time.Time, error) {
+	value, ok := args[name].(string)
+	if !ok || value == "" {
+		return nil, nil
+	}
+
+	for _, layout := range []string{db.PeriodLayout, time.DateOnly} {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return &parsed, nil
+		}
+	}
+
+	return nil, apperror.Validationf("%s must use format %q or %q", name, time.DateOnly, db.PeriodLayout)
+}
+
+// parseQuota make sure quota is a non-negative integer
+func parseQuota(quota string) (int, error) {
+	value, err := strconv.Atoi(quota)
+	if err != nil {
+		return 0, apperror.Validationf("quota must be a non-negative integer")
+	}
+
+	return value, validateQuota(value)
+}
+
+// validateQuota reject a negative quota
+func validateQuota(quota int) error {
+	if quota < 0 {
+		return apperror.Validationf("quota must be a non-negative integer")
+	}
+	return nil
+}
+
+// normalizeIcon trim the icon and lowercase its scheme and host, a non-empty icon must be an
+// absolute http or https url. Empty stays allowed.
+func normalizeIcon(icon string) (string, error) {
+	icon = strings.TrimSpace(icon)
+	if icon == "" {
+		return "", nil
+	}
+
+	parsed, err := url.Parse(icon)
+	if err != nil {
+		return "", apperror.Validationf("icon must be a valid http or https url")
+	}
+
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", apperror.Validationf("icon must be a valid http or https url")
+	}
+
+	parsed.Host = strings.ToLower(parsed.Host)
+	return parsed.String(), nil
+}
+
+// defaultMerchantIdPattern accept short opaque identifiers, deployments with uuids or a known
+// prefix tighten it through MERCHANT_ID_PATTERN
+const defaultMerchantIdPattern = `^[A-Za-z0-9_-]{1,64}$`
+
+// merchantIdPattern compile MERCHANT_ID_PATTERN once, NewSchema calls it so a broken pattern
+// fails at startup rather than on the first mutation
+var merchantIdPattern = sync.OnceValues(func() (*regexp.Regexp, error) {
+	pattern := os.Getenv("MERCHANT_ID_PATTERN")
+	if pattern == "" {
+		pattern = defaultMerchantIdPattern
+	}
+
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MERCHANT_ID_PATTERN: %w", err)
+	}
+	return compiled, nil
+})
+
+// validateMerchantId reject a merchant id not matching MERCHANT_ID_PATTERN
+func validateMerchantId(merchantId string) error {
+	pattern, err := merchantIdPattern()
+	if err != nil {
+		return err
+	}
+
+	if !pattern.MatchString(merchantId) {
+		return apperror.Validationf("merchantId must match %s", pattern)
+	}
+	return nil
+}
+
+// parseIds read a list of ids argument, rejecting more than maxIds. Duplicates are dropped
+// from the returned unique slice while ids keeps the requested order.
+func parseIds(args map[string]interface{}, name string) ([]int, []int, error) {
+	values, _ := args[name].([]interface{})
+	if len(values) > maxIds {
+		return nil, nil, apperror.Validationf("%s accepts at most %d ids, got %d", name, maxIds, len(values))
+	}
+
+	var ids, unique []int
+	seen := make(map[int]bool, len(values))
+	for _, value := range values {
+		id, _ := value.(int)
+		ids = append(ids, id)
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	return ids, unique, nil
+}
+
+// limitClamped report whether the requested limit was lowered to the applied one
+func limitClamped(args map[string]interface{}, limit int) bool {
+	requested, ok := args["limit"].(int)
+	return ok && requested != limit
+}
+
+// calcTotalPages count the pages needed for total rows, zero when there is nothing to page through
+func calcTotalPages(total int64, limit int) int {
+	if total <= 0 || limit <= 0 {
+		return 0
+	}
+
+	return int(math.Ceil(float64(total) / float64(limit)))
+}
+
+// withFilterArgs add the filter arguments shared by every query listing or counting products
+func withFilterArgs(args graphql.FieldConfigArgument) graphql.FieldConfigArgument {
+	args["search"] = &graphql.ArgumentConfig{Type: graphql.String}
+	args["fullText"] = &graphql.ArgumentConfig{
+		Type:        graphql.String,
+		Description: "Only products whose name, shortDesc or longDesc match these words",
+	}
+	args["merchantId"] = &graphql.ArgumentConfig{
+		Type:        graphql.String,
+		Description: "Only products of this merchant",
+	}
+	args["activeOn"] = &graphql.ArgumentConfig{
+		Type:        graphql.String,
+		Description: "Only products whose period overlaps this date (YYYY-MM-DD)",
+	}
+	args["startAfter"] = &graphql.ArgumentConfig{
+		Type:        graphql.String,
+		Description: "Only products starting at or after this date or datetime",
+	}
+	args["endBefore"] = &graphql.ArgumentConfig{
+		Type:        graphql.String,
+		Description: "Only products ending at or before this date or datetime",
+	}
+	args["activeOnly"] = &graphql.ArgumentConfig{
+		Type:        graphql.Boolean,
+		Description: "Only products whose period contains the current time",
+	}
+	args["hasIcon"] = &graphql.ArgumentConfig{
+		Type:        graphql.Boolean,
+		Description: "Only products with (true) or without (false) an icon, both when omitted",
+	}
+	return args
+}
+
+// parseFilter read the arguments added by withFilterArgs into params
+func parseFilter(args map[string]interface{}) (db.Params, error) {
+	var params db.Params
+	var err error
+
+	params.Search, _ = args["search"].(string)
+	fullText, _ := args["fullText"].(string)
+	params.FullText = strings.TrimSpace(fullText)
+	params.MerchantId, _ = args["merchantId"].(string)
+	params.ActiveOnly, _ = args["activeOnly"].(bool)
+	if hasIcon, ok := args["hasIcon"].(bool); ok {
+		params.HasIcon = &hasIcon
+	}
+
+	if params.ActiveOn, err = parseDateArg(args, "activeOn"); err != nil {
+		return params, err
+	}
+	if params.StartAfter, err = parseDateArg(args, "startAfter"); err != nil {
+		return params, err
+	}
+	if params.EndBefore, err = parseDateArg(args, "endBefore"); err != nil {
+		return params, err
+	}
+	return params, nil
+}
+
+// optionalString read an optional string argument, invalid when the client left it out
+func optionalString(args map[string]interface{}, name string) sql.NullString {
+	value, ok := args[name].(string)
+	return sql.NullString{String: value, Valid: ok}
+}
+
+// isActive report whether now falls within the product period. Periods are stored without a
+// zone so they are read in the server location, a missing or unparseable period is inactive.
+func isActive(ctx context.Context, product *db.ListEntity, now time.Time) bool {
+	// products may be created without a period, that is not worth a warning
+	if product.StartPeriod == nil || product.EndPeriod == nil {
+		return false
+	}
+
+	start, err := time.ParseInLocation(db.PeriodLayout, *product.StartPeriod, time.Local)
+	if err != nil {
+		logging.FromContext(ctx).WarnContext(ctx, "product startPeriod unparseable", "id", product.Id, "error", err)
+		return false
+	}
+
+	end, err := time.ParseInLocation(db.PeriodLayout, *product.EndPeriod, time.Local)
+	if err != nil {
+		logging.FromContext(ctx).WarnContext(ctx, "product endPeriod unparseable", "id", product.Id, "error", err)
+		return false
+	}
+
+	return !now.Before(start) && !now.After(end)
+}
+
+// parseMetadata encode the optional metadata argument for the JSON column, it must be an object
+func parseMetadata(args map[string]interface{}) (sql.NullString, error) {
+	value, ok := args["metadata"]
+	if !ok || value == nil {
+		return sql.NullString{}, nil
+	}
+
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return sql.NullString{}, apperror.Validationf("metadata must be a JSON object")
+	}
+
+	encoded, err := json.Marshal(object)
+	if err != nil {
+		return sql.NullString{}, apperror.Validationf("metadata must be a JSON object")
+	}
+
+	return sql.NullString{String: string(encoded), Valid: true}, nil
+}
+
+// auditMerchant return the merchant of the most recent snapshot in an audit history
+func auditMerchant(entries []*db.AuditEntity) (string, bool) {
+	for i := len(entries) - 1; i >= 0; i-- {
+		for _, snapshot := range []map[string]interface{}{entries[i].After, entries[i].Before} {
+			if merchantId, ok := snapshot["merchantId"].(string); ok {
+				return merchantId, true
+			}
+		}
+	}
+	return "", false
+}
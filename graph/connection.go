@@ -0,0 +1,114 @@
+package graph
+
+import (
+	"encoding/base64"
+	"strconv"
+	"test-sql/apperror"
+	"test-sql/db"
+)
+
+// encodeCursor turn a product id into the opaque cursor of a connection edge
+func encodeCursor(id int) string {
+	return base64.StdEncoding.EncodeToString([]byte(strconv.Itoa(id)))
+}
+
+// decodeCursor read the id back from a cursor argument, 0 when the argument is absent
+func decodeCursor(args map[string]interface{}, name string) (int, error) {
+	cursor, ok := args[name].(string)
+	if !ok || cursor == "" {
+		return 0, nil
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, apperror.Validationf("%s is not a valid cursor", name)
+	}
+
+	id, err := strconv.Atoi(string(decoded))
+	if err != nil || id < 1 {
+		return 0, apperror.Validationf("%s is not a valid cursor", name)
+	}
+	return id, nil
+}
+
+// connectionArgs read first/after/last/before into params ordered by id, forward unless last is
+// given. The limit asks for one extra row which only tells whether there is another page.
+func connectionArgs(args map[string]interface{}, pagination PaginationConfig, params *db.Params) (int, bool, error) {
+	first, hasFirst := args["first"].(int)
+	last, hasLast := args["last"].(int)
+	if hasFirst && hasLast {
+		return 0, false, apperror.Validationf("first and last cannot be used together")
+	}
+
+	size := pagination.DefaultLimit
+	switch {
+	case hasFirst:
+		size = first
+	case hasLast:
+		size = last
+	}
+	if size < 1 {
+		return 0, false, apperror.Validationf("first and last must be greater than 0")
+	}
+	size = min(size, pagination.MaxLimit)
+
+	var err error
+	if params.AfterId, err = decodeCursor(args, "after"); err != nil {
+		return 0, false, err
+	}
+	if params.BeforeId, err = decodeCursor(args, "before"); err != nil {
+		return 0, false, err
+	}
+
+	params.Page = 1
+	params.Limit = size + 1
+	params.SortBy = "id"
+	params.SortOrder = "ASC"
+	if hasLast {
+		params.SortOrder = "DESC"
+	}
+
+	return size, hasLast, nil
+}
+
+// productConnection shape the rows fetched with connectionArgs into edges and pageInfo. Backward
+// rows come newest first and are put back in id order. The side the client did not page towards
+// only reports whether a cursor was given, which Relay allows to keep it to one query.
+func productConnection(list []*db.ListEntity, size int, backward bool, params db.Params) map[string]interface{} {
+	hasMore := len(list) > size
+	if hasMore {
+		list = list[:size]
+	}
+
+	if backward {
+		for i, j := 0, len(list)-1; i < j; i, j = i+1, j-1 {
+			list[i], list[j] = list[j], list[i]
+		}
+	}
+
+	edges := make([]map[string]interface{}, 0, len(list))
+	for _, product := range list {
+		edges = append(edges, map[string]interface{}{
+			"node":   product,
+			"cursor": encodeCursor(product.Id),
+		})
+	}
+
+	pageInfo := map[string]interface{}{
+		"hasNextPage":     hasMore,
+		"hasPreviousPage": params.AfterId > 0,
+	}
+	if backward {
+		pageInfo["hasNextPage"] = params.BeforeId > 0
+		pageInfo["hasPreviousPage"] = hasMore
+	}
+	if len(edges) > 0 {
+		pageInfo["startCursor"] = edges[0]["cursor"]
+		pageInfo["endCursor"] = edges[len(edges)-1]["cursor"]
+	}
+
+	return map[string]interface{}{
+		"edges":    edges,
+		"pageInfo": pageInfo,
+	}
+}
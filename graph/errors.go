@@ -0,0 +1,84 @@
+package graph
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"runtime/debug"
+	"test-sql/apperror"
+	"test-sql/logging"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/location"
+)
+
+// resolverError translate an error into a client safe apperror.Error. Unknown errors are
+// logged with their full detail and surface as INTERNAL so no sql internals leak.
+func resolverError(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return &apperror.Error{Code: apperror.CodeNotFound, Message: "product not found"}
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		logging.FromContext(ctx).WarnContext(ctx, "resolver timed out", "error", err)
+		return apperror.ErrTimeout
+	}
+
+	logging.FromContext(ctx).ErrorContext(ctx, "resolver failed", "error", err)
+	return apperror.ErrInternal
+}
+
+// formatError format an error raised outside of execution, keeping its extensions
+func formatError(err error) gqlerrors.FormattedError {
+	formatted := gqlerrors.FormattedError{
+		Message:   err.Error(),
+		Locations: []location.SourceLocation{},
+	}
+
+	var extended gqlerrors.ExtendedError
+	if errors.As(err, &extended) {
+		formatted.Extensions = extended.Extensions()
+	}
+
+	return formatted
+}
+
+// recoverResolvers wrap every resolver of the schema so a panic is logged with its stack and
+// surfaces as INTERNAL, graphql-go would otherwise send the raw panic value to the client
+func recoverResolvers(schema graphql.Schema) {
+	for _, named := range schema.TypeMap() {
+		object, ok := named.(*graphql.Object)
+		if !ok {
+			continue
+		}
+
+		for _, field := range object.Fields() {
+			if field.Resolve != nil {
+				field.Resolve = recoverResolver(field.Resolve)
+			}
+		}
+	}
+}
+
+func recoverResolver(resolve graphql.FieldResolveFn) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (result interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logging.FromContext(p.Context).ErrorContext(p.Context, "resolver panicked", "field", p.Info.FieldName, "panic", r, "stack", string(debug.Stack()))
+				result, err = nil, apperror.ErrInternal
+			}
+		}()
+
+		return resolve(p)
+	}
+}
@@ -0,0 +1,84 @@
+package graph
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"test-sql/apperror"
+	"testing"
+)
+
+func TestResolverError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode string
+		wantMsg  string
+	}{
+		{name: "apperror kept", err: apperror.ErrForbidden, wantCode: apperror.CodeForbidden, wantMsg: apperror.ErrForbidden.Message},
+		{name: "wrapped apperror kept", err: fmt.Errorf("create: %w", apperror.ErrDuplicateMlId), wantCode: apperror.CodeDuplicateMlId, wantMsg: apperror.ErrDuplicateMlId.Message},
+		{name: "no rows", err: sql.ErrNoRows, wantCode: apperror.CodeNotFound, wantMsg: "product not found"},
+		{name: "deadline", err: fmt.Errorf("query: %w", context.DeadlineExceeded), wantCode: apperror.CodeTimeout, wantMsg: apperror.ErrTimeout.Message},
+		{name: "driver error hidden", err: errors.New("Error 1045: Access denied for user 'root'"), wantCode: apperror.CodeInternal, wantMsg: apperror.ErrInternal.Message},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var appErr *apperror.Error
+			if !errors.As(resolverError(context.Background(), tt.err), &appErr) {
+				t.Fatalf("resolverError() did not return an apperror.Error")
+			}
+			if appErr.Code != tt.wantCode || appErr.Message != tt.wantMsg {
+				t.Errorf("resolverError() = %s %q, want %s %q", appErr.Code, appErr.Message, tt.wantCode, tt.wantMsg)
+			}
+		})
+	}
+
+	if err := resolverError(context.Background(), nil); err != nil {
+		t.Errorf("resolverError(nil) = %v, want nil", err)
+	}
+}
+
+func TestResolverPanic(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		query string
+	}{
+		{name: "string panic in a list", value: "boom", query: `{ products { data { id } } }`},
+		{name: "error panic in a lookup", value: errors.New("boom"), query: `{ product(id: 1) { id } }`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := execute(t, context.Background(), &panickingProducts{value: tt.value}, nil, tt.query, nil)
+
+			encoded, err := json.Marshal(result)
+			if err != nil {
+				t.Fatalf("encode result: %v", err)
+			}
+
+			// a client only sees the json, so assert on its shape rather than the result struct
+			var response struct {
+				Data   map[string]interface{} `json:"data"`
+				Errors []struct {
+					Message    string `json:"message"`
+					Extensions struct {
+						Code string `json:"code"`
+					} `json:"extensions"`
+				} `json:"errors"`
+			}
+			if err := json.Unmarshal(encoded, &response); err != nil {
+				t.Fatalf("response %s is not valid json: %v", encoded, err)
+			}
+			if len(response.Errors) != 1 {
+				t.Fatalf("response %s, want one error", encoded)
+			}
+			if got := response.Errors[0]; got.Extensions.Code != apperror.CodeInternal || got.Message != apperror.ErrInternal.Message {
+				t.Errorf("error %s %q, want %s %q", got.Extensions.Code, got.Message, apperror.CodeInternal, apperror.ErrInternal.Message)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package graph
+
+import (
+	"context"
+	"runtime/debug"
+	"test-sql/apperror"
+	"test-sql/auth"
+	"test-sql/db"
+	"test-sql/dotenv"
+	"test-sql/locale"
+	"test-sql/logging"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Request standard graphql over http request body
+type Request struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName"`
+}
+
+// Executor run graphql requests against the product schema
+type Executor struct {
+	schema     graphql.Schema
+	merchants  db.MerchantRepository
+	pagination PaginationConfig
+	responses  *responseCache
+}
+
+// NewExecutor build the schema and return an executor bound to the repositories. Query results
+// are cached for RESPONSE_CACHE_TTL seconds (default 0, disabled), up to RESPONSE_CACHE_SIZE
+// entries (default 1000).
+func NewExecutor(products db.ProductRepository, merchants db.MerchantRepository) (*Executor, error) {
+	pagination := LoadPaginationConfig()
+	schema, err := NewSchema(products, merchants, pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	executor := &Executor{schema: schema, merchants: merchants, pagination: pagination}
+	if ttl := dotenv.GetInt("RESPONSE_CACHE_TTL", 0); ttl > 0 {
+		executor.responses = newResponseCache(time.Duration(ttl)*time.Second, dotenv.GetInt("RESPONSE_CACHE_SIZE", 1000))
+	}
+
+	return executor, nil
+}
+
+// CacheStats return the response cache counters, nil when the cache is disabled
+func (e *Executor) CacheStats() *CacheStats {
+	if e.responses == nil {
+		return nil
+	}
+	return e.responses.stats()
+}
+
+// Execute validate and run a single request, each call gets its own merchant loader and
+// product cache. A panic outside of the resolvers is logged and answered as an INTERNAL error.
+func (e *Executor) Execute(ctx context.Context, request Request) (result *graphql.Result) {
+	defer func() {
+		if r := recover(); r != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "graphql execution panicked", "panic", r, "stack", string(debug.Stack()))
+			result = &graphql.Result{Errors: []gqlerrors.FormattedError{formatError(apperror.ErrInternal)}}
+		}
+	}()
+
+	cost, err := validateRequest(request, e.pagination)
+	logging.AddRequestCounter(ctx, "graphql_cost", cost)
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Int("graphql.cost", cost))
+	if err != nil {
+		return &graphql.Result{Errors: []gqlerrors.FormattedError{formatError(err)}}
+	}
+
+	// READ_ONLY rejects every mutation before it runs, for maintenance or a replica only setup
+	mutation := IsMutation(request.Query, request.OperationName)
+	if mutation && dotenv.GetBool("READ_ONLY", false) {
+		return &graphql.Result{Errors: []gqlerrors.FormattedError{formatError(apperror.ErrReadOnly)}}
+	}
+
+	var cacheKey string
+	if e.responses != nil && !mutation {
+		merchantId, _ := auth.MerchantFromContext(ctx)
+		cacheKey = responseKey(merchantId, locale.FromContext(ctx), request)
+		if cached, ok := e.responses.get(cacheKey); ok {
+			return cached
+		}
+	}
+
+	ctx = context.WithValue(ctx, merchantLoaderKey{}, newMerchantLoader(e.merchants))
+	ctx = context.WithValue(ctx, productCacheKey{}, newProductCache())
+
+	result = e.execute(ctx, request)
+
+	if e.responses != nil {
+		// a failed mutation may still have written part of its changes
+		if mutation {
+			e.responses.invalidate()
+		} else if !result.HasErrors() {
+			e.responses.set(cacheKey, result)
+		}
+	}
+
+	return result
+}
+
+// execute parse, validate and run the request like graphql.Do, with the errors of a request
+// rejected before execution tagged GRAPHQL_PARSE_FAILED or GRAPHQL_VALIDATION_FAILED so the
+// http status can tell them from resolver failures
+func (e *Executor) execute(ctx context.Context, request Request) *graphql.Result {
+	doc, err := parseQuery(request.Query)
+	if err != nil {
+		return &graphql.Result{Errors: withCode(gqlerrors.FormatErrors(err), apperror.CodeParseFailed)}
+	}
+
+	if validation := graphql.ValidateDocument(&e.schema, doc, nil); !validation.IsValid {
+		return &graphql.Result{Errors: withCode(validation.Errors, apperror.CodeValidationFailed)}
+	}
+
+	result := graphql.Execute(graphql.ExecuteParams{
+		Schema:        e.schema,
+		AST:           doc,
+		OperationName: request.OperationName,
+		Args:          request.Variables,
+		Context:       ctx,
+	})
+
+	// without data and without a code the errors come from graphql-go rejecting the variables
+	// or the operation name, resolver errors always carry one through resolverError
+	if result.Data == nil && !hasCode(result.Errors) && ctx.Err() == nil {
+		result.Errors = withCode(result.Errors, apperror.CodeValidationFailed)
+	}
+	return result
+}
+
+// withCode set code in the extensions of errors that have none
+func withCode(errs []gqlerrors.FormattedError, code string) []gqlerrors.FormattedError {
+	for i := range errs {
+		if _, ok := errs[i].Extensions["code"]; ok {
+			continue
+		}
+		if errs[i].Extensions == nil {
+			errs[i].Extensions = map[string]interface{}{}
+		}
+		errs[i].Extensions["code"] = code
+	}
+	return errs
+}
+
+// hasCode report whether any of errs carries a code
+func hasCode(errs []gqlerrors.FormattedError) bool {
+	for _, err := range errs {
+		if _, ok := err.Extensions["code"]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,222 @@
+package graph
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"test-sql/db"
+	"testing"
+
+	"github.com/graphql-go/graphql"
+)
+
+// fakeProducts in memory ProductRepository for resolver tests, counting the calls it gets.
+// Methods a test needs that are not implemented here panic through the nil embedded interface.
+type fakeProducts struct {
+	db.ProductRepository
+	mu       sync.Mutex
+	products []*db.ListEntity
+	calls    map[string]int
+}
+
+func newFakeProducts(products ...*db.ListEntity) *fakeProducts {
+	return &fakeProducts{products: products, calls: map[string]int{}}
+}
+
+// fakeProduct build a product owned by merchantId
+func fakeProduct(id int, merchantId string) *db.ListEntity {
+	mlId := fmt.Sprintf("ML-%d", id)
+	name := fmt.Sprintf("Product %d", id)
+	longDesc := "Long description of " + name
+	return &db.ListEntity{Id: id, MlId: &mlId, MerchantId: &merchantId, Name: &name, LongDesc: &longDesc}
+}
+
+func (f *fakeProducts) called(name string) {
+	f.mu.Lock()
+	f.calls[name]++
+	f.mu.Unlock()
+}
+
+// callCount return how many times the named method ran
+func (f *fakeProducts) callCount(name string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[name]
+}
+
+// matching return the products passing the merchant and cursor filters, in id order
+func (f *fakeProducts) matching(params db.Params) []*db.ListEntity {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var list []*db.ListEntity
+	for _, product := range f.products {
+		if params.MerchantId != "" && (product.MerchantId == nil || *product.MerchantId != params.MerchantId) {
+			continue
+		}
+		if params.AfterId > 0 && product.Id <= params.AfterId {
+			continue
+		}
+		if params.BeforeId > 0 && product.Id >= params.BeforeId {
+			continue
+		}
+		list = append(list, product)
+	}
+	return list
+}
+
+func (f *fakeProducts) List(_ context.Context, params db.Params) ([]*db.ListEntity, error) {
+	f.called("List")
+
+	list := f.matching(params)
+	if params.Page > 1 {
+		list = list[min(len(list), (params.Page-1)*params.Limit):]
+	}
+	if params.Limit > 0 {
+		list = list[:min(len(list), params.Limit)]
+	}
+	return list, nil
+}
+
+func (f *fakeProducts) Count(_ context.Context, params db.Params) (int64, error) {
+	f.called("Count")
+	return int64(len(f.matching(params))), nil
+}
+
+func (f *fakeProducts) ListWithTotal(ctx context.Context, params db.Params) ([]*db.ListEntity, int64, error) {
+	f.called("ListWithTotal")
+
+	list, _ := f.List(ctx, params)
+	return list, int64(len(f.matching(params))), nil
+}
+
+func (f *fakeProducts) FindByID(_ context.Context, id int, _ ...string) (*db.ListEntity, error) {
+	f.called("FindByID")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, product := range f.products {
+		if product.Id == id {
+			return product, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeProducts) FindByIDs(_ context.Context, ids []int, _ ...string) (map[int]*db.ListEntity, error) {
+	f.called("FindByIDs")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	found := map[int]*db.ListEntity{}
+	for _, product := range f.products {
+		for _, id := range ids {
+			if product.Id == id {
+				found[id] = product
+			}
+		}
+	}
+	return found, nil
+}
+
+func (f *fakeProducts) Create(_ context.Context, input *db.ListModel, _ ...string) (*db.ListEntity, error) {
+	f.called("Create")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	product := fakeProduct(len(f.products)+1, input.MerchantId.String)
+	product.MlId = &input.MlId.String
+	product.Name = &input.Name.String
+	f.products = append(f.products, product)
+	return product, nil
+}
+
+// fakeMerchants MerchantRepository answering every id with a merchant of the same name,
+// counting the batches it gets
+type fakeMerchants struct {
+	mu      sync.Mutex
+	batches [][]string
+}
+
+func (f *fakeMerchants) FindByMerchantIds(_ context.Context, merchantIds []string) (map[string]*db.MerchantEntity, error) {
+	f.mu.Lock()
+	f.batches = append(f.batches, merchantIds)
+	f.mu.Unlock()
+
+	merchants := make(map[string]*db.MerchantEntity, len(merchantIds))
+	for i, merchantId := range merchantIds {
+		id, name := merchantId, "Merchant "+merchantId
+		merchants[merchantId] = &db.MerchantEntity{Id: i + 1, MerchantId: &id, Name: &name}
+	}
+	return merchants, nil
+}
+
+// execute run query through a new executor over products and merchants
+func execute(t *testing.T, ctx context.Context, products db.ProductRepository, merchants db.MerchantRepository, query string, variables map[string]interface{}) *graphql.Result {
+	t.Helper()
+
+	if merchants == nil {
+		merchants = &fakeMerchants{}
+	}
+	executor, err := NewExecutor(products, merchants)
+	if err != nil {
+		t.Fatalf("NewExecutor() error = %v", err)
+	}
+
+	return executor.Execute(ctx, Request{Query: query, Variables: variables})
+}
+
+// decode copy the data of a result without errors into target through its json form
+func decode(t *testing.T, result *graphql.Result, target interface{}) {
+	t.Helper()
+
+	if result.HasErrors() {
+		t.Fatalf("unexpected errors: %v", result.Errors)
+	}
+
+	encoded, err := json.Marshal(result.Data)
+	if err != nil {
+		t.Fatalf("encode data: %v", err)
+	}
+	if err := json.Unmarshal(encoded, target); err != nil {
+		t.Fatalf("decode data %s: %v", encoded, err)
+	}
+}
+
+// errorCode return the code of the first error of result, "" when it has none
+func errorCode(result *graphql.Result) string {
+	if !result.HasErrors() {
+		return ""
+	}
+	code, _ := result.Errors[0].Extensions["code"].(string)
+	return code
+}
+
+// blockingProducts ProductRepository whose page query blocks until the context is done,
+// standing in for a slow MySQL query
+type blockingProducts struct {
+	db.ProductRepository
+	started chan struct{}
+}
+
+func (b *blockingProducts) ListWithTotal(ctx context.Context, _ db.Params) ([]*db.ListEntity, int64, error) {
+	close(b.started)
+	<-ctx.Done()
+	return nil, 0, ctx.Err()
+}
+
+// panickingProducts ProductRepository whose page and id lookups panic with value
+type panickingProducts struct {
+	db.ProductRepository
+	value interface{}
+}
+
+func (p *panickingProducts) ListWithTotal(context.Context, db.Params) ([]*db.ListEntity, int64, error) {
+	panic(p.value)
+}
+
+func (p *panickingProducts) FindByID(context.Context, int, ...string) (*db.ListEntity, error) {
+	panic(p.value)
+}
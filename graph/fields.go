@@ -0,0 +1,71 @@
+package graph
+
+import (
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// fieldColumns product fields resolved from other columns than their own name
+var fieldColumns = map[string][]string{
+	"merchant": {"merchantId"},
+	"isActive": {"startPeriod", "endPeriod"},
+}
+
+// selectedFields list the product fields the client selected below the resolved field,
+// following path through nested objects (e.g. "data" for a page). Fragments are expanded.
+func selectedFields(info graphql.ResolveInfo, path ...string) []string {
+	sets := make([]*ast.SelectionSet, 0, len(info.FieldASTs))
+	for _, field := range info.FieldASTs {
+		sets = append(sets, field.SelectionSet)
+	}
+
+	for _, name := range path {
+		var children []*ast.SelectionSet
+		for _, set := range sets {
+			eachField(set, info.Fragments, map[string]bool{}, func(field *ast.Field) {
+				if field.Name.Value == name {
+					children = append(children, field.SelectionSet)
+				}
+			})
+		}
+		sets = children
+	}
+
+	// never nil, nil asks the repository for every column
+	fields := []string{}
+	for _, set := range sets {
+		eachField(set, info.Fragments, map[string]bool{}, func(field *ast.Field) {
+			name := field.Name.Value
+			if columns, ok := fieldColumns[name]; ok {
+				fields = append(fields, columns...)
+				return
+			}
+			fields = append(fields, name)
+		})
+	}
+	return fields
+}
+
+// eachField call fn for every field of a selection set, looking through fragments
+func eachField(selectionSet *ast.SelectionSet, fragments map[string]ast.Definition, visited map[string]bool, fn func(*ast.Field)) {
+	if selectionSet == nil {
+		return
+	}
+
+	for _, selection := range selectionSet.Selections {
+		switch node := selection.(type) {
+		case *ast.Field:
+			fn(node)
+		case *ast.InlineFragment:
+			eachField(node.SelectionSet, fragments, visited, fn)
+		case *ast.FragmentSpread:
+			name := node.Name.Value
+			fragment, ok := fragments[name].(*ast.FragmentDefinition)
+			if !ok || visited[name] {
+				continue
+			}
+			visited[name] = true
+			eachField(fragment.SelectionSet, fragments, visited, fn)
+		}
+	}
+}
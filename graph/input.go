@@ -0,0 +1,116 @@
+package graph
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"test-sql/apperror"
+	"test-sql/db"
+	"time"
+)
+
+// fieldErrors collect what is wrong with each input field so a form gets every problem in one
+// round trip instead of fixing them one at a time
+type fieldErrors map[string]string
+
+// add record err against field, the message of an apperror.Error is kept as is
+func (f fieldErrors) add(field string, err error) {
+	if err == nil {
+		return
+	}
+	if _, ok := f[field]; ok {
+		return
+	}
+
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) {
+		f[field] = appErr.Message
+		return
+	}
+	f[field] = err.Error()
+}
+
+// err return a single VALIDATION error listing every field, nil when all of them are fine
+func (f fieldErrors) err() error {
+	if len(f) == 0 {
+		return nil
+	}
+	return &apperror.Error{Code: apperror.CodeValidation, Message: "invalid product input", Fields: f}
+}
+
+// productInput read and validate a product input object. mlId, merchantId and name are
+// required, with partial set only mlId is, as used by upsert. Omitted fields stay invalid
+// (NULL). Strings come back trimmed with the whitespace inside name collapsed, the icon
+// normalized and metadata encoded.
+func productInput(args map[string]interface{}, partial bool) (*db.ListModel, error) {
+	input := &db.ListModel{
+		MlId:        optionalString(args, "mlId"),
+		MerchantId:  optionalString(args, "merchantId"),
+		Name:        optionalString(args, "name"),
+		LongDesc:    optionalString(args, "longDesc"),
+		ShortDesc:   optionalString(args, "shortDesc"),
+		Icon:        optionalString(args, "icon"),
+		Quota:       optionalString(args, "quota"),
+		StartPeriod: optionalString(args, "startPeriod"),
+		EndPeriod:   optionalString(args, "endPeriod"),
+	}
+
+	// trimmed before any check so "abc " can't pass as a different mlId or a blank name as set
+	for _, field := range []*sql.NullString{&input.MlId, &input.MerchantId, &input.Name, &input.LongDesc, &input.ShortDesc, &input.Icon, &input.Quota, &input.StartPeriod, &input.EndPeriod} {
+		field.String = strings.TrimSpace(field.String)
+	}
+	input.Name.String = strings.Join(strings.Fields(input.Name.String), " ")
+
+	invalid := fieldErrors{}
+
+	required := map[string]string{"mlId": input.MlId.String}
+	if !partial {
+		required["merchantId"] = input.MerchantId.String
+		required["name"] = input.Name.String
+	}
+	for field, value := range required {
+		if strings.TrimSpace(value) == "" {
+			invalid.add(field, apperror.Validationf("%s is required", field))
+		}
+	}
+
+	if strings.TrimSpace(input.MerchantId.String) != "" {
+		invalid.add("merchantId", validateMerchantId(input.MerchantId.String))
+	}
+
+	// a period is given whole or not at all, a single end could not be ordered against the
+	// stored one on update and would leave a half open period on create
+	if input.StartPeriod.Valid != input.EndPeriod.Valid {
+		invalid.add("startPeriod", apperror.Validationf("startPeriod and endPeriod must be given together"))
+		invalid.add("endPeriod", apperror.Validationf("startPeriod and endPeriod must be given together"))
+	} else if input.StartPeriod.Valid {
+		start, startErr := time.Parse(db.PeriodLayout, input.StartPeriod.String)
+		if startErr != nil {
+			invalid.add("startPeriod", apperror.Validationf("startPeriod must use format %q", db.PeriodLayout))
+		}
+		end, endErr := time.Parse(db.PeriodLayout, input.EndPeriod.String)
+		if endErr != nil {
+			invalid.add("endPeriod", apperror.Validationf("endPeriod must use format %q", db.PeriodLayout))
+		}
+		if startErr == nil && endErr == nil && start.After(end) {
+			invalid.add("startPeriod", apperror.Validationf("startPeriod must not be after endPeriod"))
+		}
+	}
+
+	if input.Quota.Valid {
+		_, err := parseQuota(input.Quota.String)
+		invalid.add("quota", err)
+	}
+
+	if input.Icon.Valid {
+		icon, err := normalizeIcon(input.Icon.String)
+		invalid.add("icon", err)
+		input.Icon.String = icon
+	}
+
+	metadata, err := parseMetadata(args)
+	invalid.add("metadata", err)
+	input.Metadata = metadata
+
+	return input, invalid.err()
+}
@@ -0,0 +1,88 @@
+package graph
+
+import (
+	"context"
+	"sync"
+	"test-sql/db"
+)
+
+type merchantLoaderKey struct{}
+
+// MerchantLoader batch merchant lookups made during a single graphql execution into one query.
+// Load only queues the key and returns a thunk, graphql-go resolves thunks after the whole
+// level has been walked so every queued key is fetched together on the first thunk call.
+type MerchantLoader struct {
+	merchants db.MerchantRepository
+	mu        sync.Mutex
+	pending   []string
+	cache     map[string]*db.MerchantEntity
+	errs      map[string]error
+}
+
+func newMerchantLoader(merchants db.MerchantRepository) *MerchantLoader {
+	return &MerchantLoader{
+		merchants: merchants,
+		cache:     map[string]*db.MerchantEntity{},
+		errs:      map[string]error{},
+	}
+}
+
+// merchantLoaderFromContext return the loader stored for the current request, nil when absent
+func merchantLoaderFromContext(ctx context.Context) *MerchantLoader {
+	if ctx == nil {
+		return nil
+	}
+
+	loader, _ := ctx.Value(merchantLoaderKey{}).(*MerchantLoader)
+	return loader
+}
+
+// Load queue a merchant id and return a thunk resolving to the merchant
+func (l *MerchantLoader) Load(ctx context.Context, merchantId string) func() (interface{}, error) {
+	l.mu.Lock()
+	if _, ok := l.cache[merchantId]; !ok {
+		l.pending = append(l.pending, merchantId)
+	}
+	l.mu.Unlock()
+
+	return func() (interface{}, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if _, ok := l.cache[merchantId]; !ok {
+			l.dispatch(ctx)
+		}
+
+		if err := l.errs[merchantId]; err != nil {
+			return nil, resolverError(ctx, err)
+		}
+
+		merchant := l.cache[merchantId]
+		if merchant == nil {
+			return nil, nil
+		}
+		return merchant, nil
+	}
+}
+
+// dispatch fetch every pending key in one query, caller must hold the lock
+func (l *MerchantLoader) dispatch(ctx context.Context) {
+	keys := make([]string, 0, len(l.pending))
+	seen := map[string]bool{}
+	for _, key := range l.pending {
+		if _, ok := l.cache[key]; ok || seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+	l.pending = nil
+
+	merchants, err := l.merchants.FindByMerchantIds(ctx, keys)
+	for _, key := range keys {
+		if err != nil {
+			l.errs[key] = err
+		}
+		l.cache[key] = merchants[key]
+	}
+}
@@ -0,0 +1,58 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"test-sql/db"
+	"testing"
+)
+
+func TestMerchantsBatchedPerPage(t *testing.T) {
+	tests := []struct {
+		name      string
+		merchants int
+		wantIds   int
+	}{
+		{name: "every product its own merchant", merchants: 10, wantIds: 10},
+		{name: "merchants shared by products", merchants: 3, wantIds: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var products []*db.ListEntity
+			for i := 1; i <= 10; i++ {
+				products = append(products, fakeProduct(i, fmt.Sprintf("M%03d", i%tt.merchants)))
+			}
+			merchants := &fakeMerchants{}
+
+			result := execute(t, context.Background(), newFakeProducts(products...), merchants,
+				`{ products(limit: 10) { data { id merchant { merchantId name } } } }`, nil)
+
+			var data struct {
+				Products struct {
+					Data []struct {
+						Id       int
+						Merchant *struct{ MerchantId string }
+					}
+				}
+			}
+			decode(t, result, &data)
+
+			if len(data.Products.Data) != 10 {
+				t.Fatalf("%d products returned, want 10", len(data.Products.Data))
+			}
+			for _, product := range data.Products.Data {
+				if product.Merchant == nil {
+					t.Fatalf("product %d has no merchant", product.Id)
+				}
+			}
+
+			if len(merchants.batches) != 1 {
+				t.Fatalf("%d merchant queries, want 1: %v", len(merchants.batches), merchants.batches)
+			}
+			if ids := merchants.batches[0]; len(ids) != tt.wantIds {
+				t.Errorf("merchant ids %v fetched, want %d distinct ids", ids, tt.wantIds)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package graph
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"test-sql/auth"
+
+	"github.com/graphql-go/graphql"
+)
+
+// protectedProductFields read PROTECTED_PRODUCT_FIELDS, the comma separated Product fields
+// hidden from anonymous callers. None by default.
+func protectedProductFields() []string {
+	var fields []string
+	for _, field := range strings.Split(os.Getenv("PROTECTED_PRODUCT_FIELDS"), ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// protectFields make the named fields of object resolve to null unless the request carries an
+// authenticated merchant. An unknown name is an error so a typo can't leave a field exposed.
+func protectFields(object *graphql.Object, names []string) error {
+	fields := object.Fields()
+	for _, name := range names {
+		field, ok := fields[name]
+		if !ok {
+			return fmt.Errorf("cannot protect unknown field %s.%s", object.Name(), name)
+		}
+
+		resolve := field.Resolve
+		if resolve == nil {
+			resolve = graphql.DefaultResolveFn
+		}
+
+		field.Resolve = func(p graphql.ResolveParams) (interface{}, error) {
+			if _, ok := auth.MerchantFromContext(p.Context); !ok {
+				return nil, nil
+			}
+			return resolve(p)
+		}
+		field.Description = strings.TrimSpace(field.Description + " Null unless the request is authenticated.")
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package graph
+
+import (
+	"context"
+	"strings"
+	"test-sql/auth"
+	"testing"
+)
+
+func TestProtectedProductFields(t *testing.T) {
+	tests := []struct {
+		name         string
+		protected    string
+		merchant     string
+		wantLongDesc bool
+		wantMlId     bool
+	}{
+		{name: "nothing protected", wantLongDesc: true, wantMlId: true},
+		{name: "anonymous", protected: "longDesc", wantLongDesc: false, wantMlId: true},
+		{name: "authenticated", protected: "longDesc", merchant: "M001", wantLongDesc: true, wantMlId: true},
+		{name: "anonymous with two fields", protected: " longDesc , mlId ", wantLongDesc: false, wantMlId: false},
+		{name: "authenticated as another merchant", protected: "longDesc,mlId", merchant: "M002", wantLongDesc: true, wantMlId: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PROTECTED_PRODUCT_FIELDS", tt.protected)
+
+			ctx := context.Background()
+			if tt.merchant != "" {
+				ctx = auth.WithMerchant(ctx, tt.merchant)
+			}
+			result := execute(t, ctx, newFakeProducts(fakeProduct(1, "M001")), nil,
+				`{ product(id: 1) { id name mlId longDesc } }`, nil)
+
+			var data struct {
+				Product struct {
+					Id       int
+					Name     *string
+					MlId     *string
+					LongDesc *string
+				}
+			}
+			decode(t, result, &data)
+
+			if data.Product.Id != 1 || data.Product.Name == nil {
+				t.Errorf("unprotected fields id %d name %v, want them set", data.Product.Id, data.Product.Name)
+			}
+			if got := data.Product.LongDesc != nil; got != tt.wantLongDesc {
+				t.Errorf("longDesc present %t, want %t", got, tt.wantLongDesc)
+			}
+			if got := data.Product.MlId != nil; got != tt.wantMlId {
+				t.Errorf("mlId present %t, want %t", got, tt.wantMlId)
+			}
+		})
+	}
+}
+
+func TestProtectedProductFieldsUnknown(t *testing.T) {
+	t.Setenv("PROTECTED_PRODUCT_FIELDS", "longDesc,secretNotes")
+
+	_, err := NewSchema(newFakeProducts(), &fakeMerchants{}, testPagination)
+	if err == nil || !strings.Contains(err.Error(), "Product.secretNotes") {
+		t.Fatalf("NewSchema() error = %v, want one naming Product.secretNotes", err)
+	}
+}
@@ -0,0 +1,53 @@
+package graph
+
+import (
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+// parseQuery parse a request string into its document
+func parseQuery(query string) (*ast.Document, error) {
+	return parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{
+			Body: []byte(query),
+			Name: "GraphQL request",
+		}),
+	})
+}
+
+// findOperation pick the operation that will be executed, nil when it can't be determined
+func findOperation(doc *ast.Document, operationName string) *ast.OperationDefinition {
+	var found *ast.OperationDefinition
+	for _, definition := range doc.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+
+		if operationName == "" {
+			if found != nil {
+				return nil
+			}
+			found = operation
+			continue
+		}
+
+		if operation.Name != nil && operation.Name.Value == operationName {
+			return operation
+		}
+	}
+
+	return found
+}
+
+// IsMutation report whether the operation selected by operationName is a mutation
+func IsMutation(query string, operationName string) bool {
+	doc, err := parseQuery(query)
+	if err != nil {
+		return false
+	}
+
+	operation := findOperation(doc, operationName)
+	return operation != nil && operation.Operation == ast.OperationTypeMutation
+}
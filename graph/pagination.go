@@ -0,0 +1,61 @@
+package graph
+
+import (
+	"test-sql/apperror"
+	"test-sql/dotenv"
+)
+
+// PaginationConfig paging policy of the list queries, read once at startup
+type PaginationConfig struct {
+	// DefaultLimit rows per page when the client gives no limit
+	DefaultLimit int
+	// MaxLimit cap on the limit a client may ask for
+	MaxLimit int
+	// DefaultPage page served when the client gives no page
+	DefaultPage int
+}
+
+// LoadPaginationConfig read PAGINATION_DEFAULT_LIMIT (default 10), PAGINATION_MAX_LIMIT
+// (default 100) and PAGINATION_DEFAULT_PAGE (default 1). Values below 1 fall back to the
+// defaults and the default limit never exceeds the max.
+func LoadPaginationConfig() PaginationConfig {
+	config := PaginationConfig{
+		DefaultLimit: dotenv.GetInt("PAGINATION_DEFAULT_LIMIT", 10),
+		MaxLimit:     dotenv.GetInt("PAGINATION_MAX_LIMIT", 100),
+		DefaultPage:  dotenv.GetInt("PAGINATION_DEFAULT_PAGE", 1),
+	}
+
+	if config.MaxLimit < 1 {
+		config.MaxLimit = 100
+	}
+	if config.DefaultLimit < 1 {
+		config.DefaultLimit = 10
+	}
+	if config.DefaultPage < 1 {
+		config.DefaultPage = 1
+	}
+	config.DefaultLimit = min(config.DefaultLimit, config.MaxLimit)
+
+	return config
+}
+
+// resolve read page and limit arguments, rejecting values below 1 and capping limit to MaxLimit
+func (c PaginationConfig) resolve(args map[string]interface{}) (int, int, error) {
+	limit := c.DefaultLimit
+	page := c.DefaultPage
+
+	if val, ok := args["limit"].(int); ok {
+		if val < 1 {
+			return 0, 0, apperror.Validationf("limit must be greater than 0")
+		}
+		limit = min(val, c.MaxLimit)
+	}
+	if val, ok := args["page"].(int); ok {
+		if val < 1 {
+			return 0, 0, apperror.Validationf("page must be greater than 0")
+		}
+		page = val
+	}
+
+	return page, limit, nil
+}
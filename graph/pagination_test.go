@@ -0,0 +1,122 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"test-sql/apperror"
+	"test-sql/db"
+	"testing"
+)
+
+func TestPaginationResolve(t *testing.T) {
+	config := PaginationConfig{DefaultLimit: 10, MaxLimit: 100, DefaultPage: 1}
+
+	tests := []struct {
+		name        string
+		args        map[string]interface{}
+		wantPage    int
+		wantLimit   int
+		wantClamped bool
+		wantErr     bool
+	}{
+		{name: "defaults", args: map[string]interface{}{}, wantPage: 1, wantLimit: 10},
+		{name: "within bounds", args: map[string]interface{}{"page": 3, "limit": 25}, wantPage: 3, wantLimit: 25},
+		{name: "at the max", args: map[string]interface{}{"limit": 100}, wantPage: 1, wantLimit: 100},
+		{name: "oversized limit is capped", args: map[string]interface{}{"limit": 1000000}, wantPage: 1, wantLimit: 100, wantClamped: true},
+		{name: "zero limit", args: map[string]interface{}{"limit": 0}, wantErr: true},
+		{name: "negative limit", args: map[string]interface{}{"limit": -5}, wantErr: true},
+		{name: "zero page", args: map[string]interface{}{"page": 0}, wantErr: true},
+		{name: "negative page", args: map[string]interface{}{"page": -1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, limit, err := config.resolve(tt.args)
+			if tt.wantErr {
+				var appErr *apperror.Error
+				if !errors.As(err, &appErr) || appErr.Code != apperror.CodeValidation {
+					t.Fatalf("resolve() error = %v, want a VALIDATION error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolve() error = %v", err)
+			}
+
+			if page != tt.wantPage || limit != tt.wantLimit {
+				t.Errorf("resolve() = page %d limit %d, want page %d limit %d", page, limit, tt.wantPage, tt.wantLimit)
+			}
+			if clamped := limitClamped(tt.args, limit); clamped != tt.wantClamped {
+				t.Errorf("limitClamped() = %t, want %t", clamped, tt.wantClamped)
+			}
+		})
+	}
+}
+
+func TestLoadPaginationConfig(t *testing.T) {
+	tests := []struct {
+		name         string
+		defaultLimit string
+		maxLimit     string
+		defaultPage  string
+		want         PaginationConfig
+	}{
+		{name: "defaults", want: PaginationConfig{DefaultLimit: 10, MaxLimit: 100, DefaultPage: 1}},
+		{name: "overrides", defaultLimit: "25", maxLimit: "50", defaultPage: "2", want: PaginationConfig{DefaultLimit: 25, MaxLimit: 50, DefaultPage: 2}},
+		{name: "default limit capped to the maximum", defaultLimit: "80", maxLimit: "20", want: PaginationConfig{DefaultLimit: 20, MaxLimit: 20, DefaultPage: 1}},
+		{name: "values below 1 fall back", defaultLimit: "0", maxLimit: "-5", defaultPage: "0", want: PaginationConfig{DefaultLimit: 10, MaxLimit: 100, DefaultPage: 1}},
+		{name: "not a number falls back", defaultLimit: "ten", maxLimit: "lots", defaultPage: "first", want: PaginationConfig{DefaultLimit: 10, MaxLimit: 100, DefaultPage: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PAGINATION_DEFAULT_LIMIT", tt.defaultLimit)
+			t.Setenv("PAGINATION_MAX_LIMIT", tt.maxLimit)
+			t.Setenv("PAGINATION_DEFAULT_PAGE", tt.defaultPage)
+
+			if got := LoadPaginationConfig(); got != tt.want {
+				t.Errorf("LoadPaginationConfig() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProductsUsePaginationConfig(t *testing.T) {
+	t.Setenv("PAGINATION_DEFAULT_LIMIT", "3")
+	t.Setenv("PAGINATION_MAX_LIMIT", "5")
+
+	var products []*db.ListEntity
+	for i := 1; i <= 12; i++ {
+		products = append(products, fakeProduct(i, "M001"))
+	}
+
+	tests := []struct {
+		name        string
+		query       string
+		wantLimit   int
+		wantClamped bool
+		wantRows    int
+	}{
+		{name: "default limit", query: `{ products { limit clamped data { id } } }`, wantLimit: 3, wantRows: 3},
+		{name: "limit over the maximum", query: `{ products(limit: 50) { limit clamped data { id } } }`, wantLimit: 5, wantClamped: true, wantRows: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := execute(t, context.Background(), newFakeProducts(products...), nil, tt.query, nil)
+
+			var data struct {
+				Products struct {
+					Limit   int
+					Clamped bool
+					Data    []struct{ Id int }
+				}
+			}
+			decode(t, result, &data)
+
+			if data.Products.Limit != tt.wantLimit || data.Products.Clamped != tt.wantClamped || len(data.Products.Data) != tt.wantRows {
+				t.Errorf("limit %d clamped %t rows %d, want %d %t %d", data.Products.Limit, data.Products.Clamped, len(data.Products.Data), tt.wantLimit, tt.wantClamped, tt.wantRows)
+			}
+		})
+	}
+}
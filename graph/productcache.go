@@ -0,0 +1,105 @@
+package graph
+
+import (
+	"context"
+	"sync"
+	"test-sql/db"
+)
+
+type productCacheKey struct{}
+
+type productCacheEntry struct {
+	product *db.ListEntity
+	// fields the product was loaded with, nil when every column was selected
+	fields map[string]bool
+}
+
+// productCache remember the products loaded by id during a single graphql execution so the
+// same id is fetched once. It lives in the request context and dies with it.
+type productCache struct {
+	mu      sync.Mutex
+	entries map[int]productCacheEntry
+}
+
+func newProductCache() *productCache {
+	return &productCache{entries: map[int]productCacheEntry{}}
+}
+
+// productCacheFromContext return the cache stored for the current request, nil when absent
+func productCacheFromContext(ctx context.Context) *productCache {
+	if ctx == nil {
+		return nil
+	}
+
+	cache, _ := ctx.Value(productCacheKey{}).(*productCache)
+	return cache
+}
+
+// findProductByID load a product through the request cache. A cached product is reused when
+// it was loaded with at least the requested fields.
+func findProductByID(ctx context.Context, products db.ProductRepository, id int, fields ...string) (*db.ListEntity, error) {
+	cache := productCacheFromContext(ctx)
+	if cache == nil {
+		return products.FindByID(ctx, id, fields...)
+	}
+
+	cache.mu.Lock()
+	entry, ok := cache.entries[id]
+	cache.mu.Unlock()
+	if ok && entry.covers(fields) {
+		return entry.product, nil
+	}
+
+	// widen the reload to the fields already cached so the new entry still serves earlier ones
+	if ok && entry.fields != nil && fields != nil {
+		for field := range entry.fields {
+			fields = append(fields, field)
+		}
+	}
+
+	product, err := products.FindByID(ctx, id, fields...)
+	if err != nil {
+		return nil, err
+	}
+
+	entry = productCacheEntry{product: product}
+	if fields != nil {
+		entry.fields = make(map[string]bool, len(fields))
+		for _, field := range fields {
+			entry.fields[field] = true
+		}
+	}
+
+	cache.mu.Lock()
+	cache.entries[id] = entry
+	cache.mu.Unlock()
+	return product, nil
+}
+
+// forgetProduct drop a cached product after a mutation changed it
+func forgetProduct(ctx context.Context, id int) {
+	cache := productCacheFromContext(ctx)
+	if cache == nil {
+		return
+	}
+
+	cache.mu.Lock()
+	delete(cache.entries, id)
+	cache.mu.Unlock()
+}
+
+func (e productCacheEntry) covers(fields []string) bool {
+	if e.fields == nil {
+		return true
+	}
+	if fields == nil {
+		return false
+	}
+
+	for _, field := range fields {
+		if field != "id" && !e.fields[field] {
+			return false
+		}
+	}
+	return true
+}
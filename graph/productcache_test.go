@@ -0,0 +1,88 @@
+package graph
+
+import (
+	"context"
+	"testing"
+)
+
+// root fields resolve in no fixed order, so every case selects the same fields on each alias
+func TestProductCache(t *testing.T) {
+	tests := []struct {
+		name      string
+		requests  []string
+		wantCalls int
+	}{
+		{
+			name:      "same id twice in one request",
+			requests:  []string{`{ a: product(id: 5) { id name } b: product(id: 5) { id name } }`},
+			wantCalls: 1,
+		},
+		{
+			name:      "same id through a fragment",
+			requests:  []string{`{ a: product(id: 5) { ...Card } b: product(id: 5) { ...Card } } fragment Card on Product { id name mlId }`},
+			wantCalls: 1,
+		},
+		{
+			name:      "different ids",
+			requests:  []string{`{ a: product(id: 5) { id } b: product(id: 6) { id } }`},
+			wantCalls: 2,
+		},
+		{
+			name:      "not shared between requests",
+			requests:  []string{`{ product(id: 5) { id } }`, `{ product(id: 5) { id } }`},
+			wantCalls: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			products := newFakeProducts(fakeProduct(5, "M001"), fakeProduct(6, "M001"))
+
+			executor, err := NewExecutor(products, &fakeMerchants{})
+			if err != nil {
+				t.Fatalf("NewExecutor() error = %v", err)
+			}
+			for _, query := range tt.requests {
+				if result := executor.Execute(context.Background(), Request{Query: query}); result.HasErrors() {
+					t.Fatalf("unexpected errors: %v", result.Errors)
+				}
+			}
+
+			if calls := products.callCount("FindByID"); calls != tt.wantCalls {
+				t.Errorf("FindByID called %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestProductCacheEntryCovers(t *testing.T) {
+	tests := []struct {
+		name   string
+		loaded []string
+		fields []string
+		want   bool
+	}{
+		{name: "same fields", loaded: []string{"id", "name"}, fields: []string{"id", "name"}, want: true},
+		{name: "fewer fields", loaded: []string{"id", "name", "mlId"}, fields: []string{"name"}, want: true},
+		{name: "more fields", loaded: []string{"id"}, fields: []string{"id", "longDesc"}, want: false},
+		{name: "id is always there", loaded: []string{"name"}, fields: []string{"id", "name"}, want: true},
+		{name: "loaded with every column", loaded: nil, fields: []string{"longDesc"}, want: true},
+		{name: "every column asked", loaded: []string{"id", "name"}, fields: nil, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := productCacheEntry{}
+			if tt.loaded != nil {
+				entry.fields = map[string]bool{}
+				for _, field := range tt.loaded {
+					entry.fields[field] = true
+				}
+			}
+
+			if got := entry.covers(tt.fields); got != tt.want {
+				t.Errorf("covers(%q) = %t, want %t", tt.fields, got, tt.want)
+			}
+		})
+	}
+}
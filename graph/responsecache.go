@@ -0,0 +1,95 @@
+package graph
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/graphql-go/graphql"
+)
+
+// CacheStats hit and miss counters of the response cache since startup
+type CacheStats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+}
+
+type responseEntry struct {
+	result    *graphql.Result
+	expiresAt time.Time
+}
+
+// responseCache keep successful query results for a short ttl, keyed by the caller's merchant
+// and the request so one merchant never sees another's response. It is local to the process
+// and any mutation run here drops everything, other instances only see a write once their
+// entries expire.
+type responseCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	size    int
+	entries map[string]responseEntry
+	hits    atomic.Int64
+	misses  atomic.Int64
+}
+
+func newResponseCache(ttl time.Duration, size int) *responseCache {
+	return &responseCache{ttl: ttl, size: size, entries: map[string]responseEntry{}}
+}
+
+// responseKey hash the merchant scope and locale with the query, operation and variables.
+// Variables are encoded as json whose object keys are sorted, so their order does not matter.
+func responseKey(merchantId string, locale string, request Request) string {
+	encoded, _ := json.Marshal([]interface{}{merchantId, locale, request.Query, request.OperationName, request.Variables})
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:])
+}
+
+func (c *responseCache) get(key string) (*graphql.Result, bool) {
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+
+	if !ok || time.Now().After(entry.expiresAt) {
+		c.misses.Add(1)
+		return nil, false
+	}
+
+	c.hits.Add(1)
+	return entry.result, true
+}
+
+// set store a result, when the cache is still full after dropping expired entries the result
+// is not cached rather than evicting a live one
+func (c *responseCache) set(key string, result *graphql.Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if len(c.entries) >= c.size {
+		for key, entry := range c.entries {
+			if now.After(entry.expiresAt) {
+				delete(c.entries, key)
+			}
+		}
+		if len(c.entries) >= c.size {
+			return
+		}
+	}
+
+	c.entries[key] = responseEntry{result: result, expiresAt: now.Add(c.ttl)}
+}
+
+// invalidate forget every cached response, called after any mutation
+func (c *responseCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = map[string]responseEntry{}
+}
+
+func (c *responseCache) stats() *CacheStats {
+	return &CacheStats{Hits: c.hits.Load(), Misses: c.misses.Load()}
+}
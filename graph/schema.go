@@ -0,0 +1,679 @@
+package graph
+
+import (
+	"database/sql"
+	"errors"
+	"test-sql/auth"
+	"test-sql/db"
+	"test-sql/locale"
+	"time"
+
+	"github.com/graphql-go/graphql"
+)
+
+// NewSchema build the graphql schema with its resolvers bound to the repositories, list queries
+// page according to pagination
+func NewSchema(products db.ProductRepository, merchants db.MerchantRepository, pagination PaginationConfig) (graphql.Schema, error) {
+	if _, err := merchantIdPattern(); err != nil {
+		return graphql.Schema{}, err
+	}
+
+	var merchantType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Merchant",
+		Fields: graphql.Fields{
+			"id": &graphql.Field{Type: graphql.Int},
+			"merchantId": &graphql.Field{
+				Type: graphql.String,
+			},
+			"name": &graphql.Field{
+				Type: graphql.String,
+			},
+		},
+	})
+
+	var productType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Product",
+		Fields: graphql.Fields{
+			"id": &graphql.Field{Type: graphql.Int},
+			"mlId": &graphql.Field{
+				Type: graphql.String,
+			},
+			"merchantId": &graphql.Field{
+				Type: graphql.String,
+			},
+			"name": &graphql.Field{
+				Type: graphql.String,
+			},
+			"longDesc": &graphql.Field{
+				Type: graphql.String,
+			},
+			"shortDesc": &graphql.Field{
+				Type: graphql.String,
+			},
+			"icon": &graphql.Field{
+				Type: graphql.String,
+			},
+			// quota is stored as a string column but exposed as Int since it is always numeric,
+			// legacy rows that don't parse resolve to null
+			"quota": &graphql.Field{
+				Type: graphql.Int,
+			},
+			"startPeriod": &graphql.Field{
+				Type: graphql.String,
+			},
+			"endPeriod": &graphql.Field{
+				Type: graphql.String,
+			},
+			"createdAt": &graphql.Field{
+				Type: graphql.String,
+			},
+			"updatedAt": &graphql.Field{
+				Type: graphql.String,
+			},
+			"metadata": &graphql.Field{
+				Type:        jsonScalar,
+				Description: "Free form JSON object attached to the product",
+			},
+			"isActive": &graphql.Field{
+				Type:        graphql.Boolean,
+				Description: "Whether the current time falls within startPeriod and endPeriod",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					product, ok := p.Source.(*db.ListEntity)
+					if !ok {
+						return false, nil
+					}
+					return isActive(p.Context, product, time.Now()), nil
+				},
+			},
+			"merchant": &graphql.Field{
+				Type: merchantType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					product, ok := p.Source.(*db.ListEntity)
+					if !ok || product.MerchantId == nil {
+						return nil, nil
+					}
+
+					loader := merchantLoaderFromContext(p.Context)
+					if loader == nil {
+						loader = newMerchantLoader(merchants)
+					}
+					return loader.Load(p.Context, *product.MerchantId), nil
+				},
+			},
+		},
+	})
+
+	if err := protectFields(productType, protectedProductFields()); err != nil {
+		return graphql.Schema{}, err
+	}
+
+	var productSortFieldType = graphql.NewEnum(graphql.EnumConfig{
+		Name: "ProductSortField",
+		Values: graphql.EnumValueConfigMap{
+			"id":          &graphql.EnumValueConfig{Value: "id"},
+			"name":        &graphql.EnumValueConfig{Value: "name"},
+			"startPeriod": &graphql.EnumValueConfig{Value: "startPeriod"},
+			"endPeriod":   &graphql.EnumValueConfig{Value: "endPeriod"},
+		},
+	})
+
+	var sortOrderType = graphql.NewEnum(graphql.EnumConfig{
+		Name: "SortOrder",
+		Values: graphql.EnumValueConfigMap{
+			"ASC":  &graphql.EnumValueConfig{Value: "ASC"},
+			"DESC": &graphql.EnumValueConfig{Value: "DESC"},
+		},
+	})
+
+	var productPaginationType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "ProductPagination",
+		Fields: graphql.Fields{
+			"page": &graphql.Field{Type: graphql.Int},
+			"limit": &graphql.Field{
+				Type:        graphql.Int,
+				Description: "Limit applied after capping the requested one",
+			},
+			"clamped": &graphql.Field{
+				Type:        graphql.Boolean,
+				Description: "Whether the requested limit was lowered to the applied one",
+			},
+			"totalData":   &graphql.Field{Type: graphql.Int},
+			"totalPages":  &graphql.Field{Type: graphql.Int},
+			"hasNextPage": &graphql.Field{Type: graphql.Boolean},
+			"hasPrevPage": &graphql.Field{Type: graphql.Boolean},
+			"data":        &graphql.Field{Type: graphql.NewList(productType)},
+		},
+	})
+
+	var pageInfoType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "PageInfo",
+		Fields: graphql.Fields{
+			"hasNextPage":     &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+			"hasPreviousPage": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+			"startCursor":     &graphql.Field{Type: graphql.String},
+			"endCursor":       &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	var productEdgeType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "ProductEdge",
+		Fields: graphql.Fields{
+			"node":   &graphql.Field{Type: productType},
+			"cursor": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+
+	var productConnectionType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "ProductConnection",
+		Fields: graphql.Fields{
+			"edges":    &graphql.Field{Type: graphql.NewList(productEdgeType)},
+			"pageInfo": &graphql.Field{Type: graphql.NewNonNull(pageInfoType)},
+		},
+	})
+
+	var productAuditEntryType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "ProductAuditEntry",
+		Fields: graphql.Fields{
+			"id":        &graphql.Field{Type: graphql.Int},
+			"productId": &graphql.Field{Type: graphql.Int},
+			"action":    &graphql.Field{Type: graphql.String},
+			"actor": &graphql.Field{
+				Type:        graphql.String,
+				Description: "Merchant that made the change, null for anonymous requests",
+			},
+			"before":    &graphql.Field{Type: jsonScalar},
+			"after":     &graphql.Field{Type: jsonScalar},
+			"createdAt": &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	var merchantProductCountType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "MerchantProductCount",
+		Fields: graphql.Fields{
+			"merchantId":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"productCount": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"merchant": &graphql.Field{
+				Type: merchantType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					count, ok := p.Source.(*db.MerchantCountEntity)
+					if !ok {
+						return nil, nil
+					}
+
+					loader := merchantLoaderFromContext(p.Context)
+					if loader == nil {
+						loader = newMerchantLoader(merchants)
+					}
+					return loader.Load(p.Context, count.MerchantId), nil
+				},
+			},
+		},
+	})
+
+	var merchantPaginationType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "MerchantProductCountPagination",
+		Fields: graphql.Fields{
+			"page": &graphql.Field{Type: graphql.Int},
+			"limit": &graphql.Field{
+				Type:        graphql.Int,
+				Description: "Limit applied after capping the requested one",
+			},
+			"clamped": &graphql.Field{
+				Type:        graphql.Boolean,
+				Description: "Whether the requested limit was lowered to the applied one",
+			},
+			"totalData":   &graphql.Field{Type: graphql.Int},
+			"totalPages":  &graphql.Field{Type: graphql.Int},
+			"hasNextPage": &graphql.Field{Type: graphql.Boolean},
+			"hasPrevPage": &graphql.Field{Type: graphql.Boolean},
+			"data":        &graphql.Field{Type: graphql.NewList(merchantProductCountType)},
+		},
+	})
+
+	// viewerType the identity attached to the request, read from the context only
+	var viewerType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Viewer",
+		Fields: graphql.Fields{
+			"merchantId": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+
+	var rootQuery = graphql.NewObject(graphql.ObjectConfig{
+		Name: "RootQuery",
+		Fields: graphql.Fields{
+			"products": &graphql.Field{
+				Type: productPaginationType,
+				Args: withFilterArgs(graphql.FieldConfigArgument{
+					"page":      &graphql.ArgumentConfig{Type: graphql.Int},
+					"limit":     &graphql.ArgumentConfig{Type: graphql.Int},
+					"sortBy":    &graphql.ArgumentConfig{Type: productSortFieldType, DefaultValue: "id"},
+					"sortOrder": &graphql.ArgumentConfig{Type: sortOrderType, DefaultValue: "ASC"},
+				}),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					page, limit, err := pagination.resolve(p.Args)
+					if err != nil {
+						return nil, resolverError(p.Context, err)
+					}
+
+					params, err := parseFilter(p.Args)
+					if err != nil {
+						return nil, resolverError(p.Context, err)
+					}
+					params.Page = page
+					params.Limit = limit
+					params.SortBy, _ = p.Args["sortBy"].(string)
+					params.SortOrder, _ = p.Args["sortOrder"].(string)
+					params.Fields = selectedFields(p.Info, "data")
+
+					list, total, err := products.ListWithTotal(p.Context, params)
+					if err != nil {
+						return nil, resolverError(p.Context, err)
+					}
+
+					totalPages := calcTotalPages(total, limit)
+					return map[string]interface{}{
+						"data":        list,
+						"page":        page,
+						"limit":       limit,
+						"clamped":     limitClamped(p.Args, limit),
+						"totalData":   int(total),
+						"totalPages":  totalPages,
+						"hasNextPage": page < totalPages,
+						"hasPrevPage": page > 1,
+					}, nil
+				},
+			},
+			"productsConnection": &graphql.Field{
+				Type:        productConnectionType,
+				Description: "Products ordered by id as a Relay connection, paged with first/after or last/before",
+				Args: withFilterArgs(graphql.FieldConfigArgument{
+					"first":  &graphql.ArgumentConfig{Type: graphql.Int},
+					"after":  &graphql.ArgumentConfig{Type: graphql.String},
+					"last":   &graphql.ArgumentConfig{Type: graphql.Int},
+					"before": &graphql.ArgumentConfig{Type: graphql.String},
+				}),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					params, err := parseFilter(p.Args)
+					if err != nil {
+						return nil, resolverError(p.Context, err)
+					}
+
+					size, backward, err := connectionArgs(p.Args, pagination, &params)
+					if err != nil {
+						return nil, resolverError(p.Context, err)
+					}
+					params.Fields = selectedFields(p.Info, "edges", "node")
+
+					list, err := products.List(p.Context, params)
+					if err != nil {
+						return nil, resolverError(p.Context, err)
+					}
+					return productConnection(list, size, backward, params), nil
+				},
+			},
+			"productsCount": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Int),
+				Description: "Total of products matching the filters without fetching any row",
+				Args:        withFilterArgs(graphql.FieldConfigArgument{}),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					params, err := parseFilter(p.Args)
+					if err != nil {
+						return nil, resolverError(p.Context, err)
+					}
+
+					total, err := products.Count(p.Context, params)
+					if err != nil {
+						return nil, resolverError(p.Context, err)
+					}
+					return int(total), nil
+				},
+			},
+			"merchants": &graphql.Field{
+				Type:        merchantPaginationType,
+				Description: "Merchants having products with their product count, ordered by merchantId",
+				Args: graphql.FieldConfigArgument{
+					"page":  &graphql.ArgumentConfig{Type: graphql.Int},
+					"limit": &graphql.ArgumentConfig{Type: graphql.Int},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					page, limit, err := pagination.resolve(p.Args)
+					if err != nil {
+						return nil, resolverError(p.Context, err)
+					}
+
+					list, total, err := products.MerchantCounts(p.Context, page, limit)
+					if err != nil {
+						return nil, resolverError(p.Context, err)
+					}
+
+					totalPages := calcTotalPages(total, limit)
+					return map[string]interface{}{
+						"data":        list,
+						"page":        page,
+						"limit":       limit,
+						"clamped":     limitClamped(p.Args, limit),
+						"totalData":   int(total),
+						"totalPages":  totalPages,
+						"hasNextPage": page < totalPages,
+						"hasPrevPage": page > 1,
+					}, nil
+				},
+			},
+			"productAuditLog": &graphql.Field{
+				Type:        graphql.NewList(productAuditEntryType),
+				Description: "Changes made to a product, oldest first",
+				Args: graphql.FieldConfigArgument{
+					"productId": &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(graphql.Int),
+					},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					productId, _ := p.Args["productId"].(int)
+
+					entries, err := products.AuditLog(p.Context, productId)
+					if err != nil {
+						return nil, resolverError(p.Context, err)
+					}
+
+					// the history belongs to the merchant owning the product in its latest snapshot
+					if merchantId, ok := auditMerchant(entries); ok {
+						if err := auth.Authorize(p.Context, merchantId); err != nil {
+							return nil, resolverError(p.Context, err)
+						}
+					}
+					return entries, nil
+				},
+			},
+			"me": &graphql.Field{
+				Type:        viewerType,
+				Description: "The authenticated caller, null for anonymous requests",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					merchantId, ok := auth.MerchantFromContext(p.Context)
+					if !ok {
+						return nil, nil
+					}
+					return map[string]interface{}{"merchantId": merchantId}, nil
+				},
+			},
+			"locale": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.String),
+				Description: "The locale resolved from Accept-Language, descriptions will be served in it",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return locale.FromContext(p.Context), nil
+				},
+			},
+			"product": &graphql.Field{
+				Type: productType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{
+						Type: graphql.Int,
+					},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					id, ok := p.Args["id"].(int)
+					if ok {
+						data, err := findProductByID(p.Context, products, id, selectedFields(p.Info)...)
+						if err != nil {
+							return nil, resolverError(p.Context, err)
+						}
+						return data, nil
+					}
+					return nil, nil
+				},
+			},
+			"productsByIds": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(productType)),
+				Description: "Products in the order of ids, null where an id does not exist",
+				Args: graphql.FieldConfigArgument{
+					"ids": &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.Int))),
+					},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					ids, unique, err := parseIds(p.Args, "ids")
+					if err != nil {
+						return nil, resolverError(p.Context, err)
+					}
+
+					found, err := products.FindByIDs(p.Context, unique, selectedFields(p.Info)...)
+					if err != nil {
+						return nil, resolverError(p.Context, err)
+					}
+
+					result := make([]interface{}, len(ids))
+					for i, id := range ids {
+						if product, ok := found[id]; ok {
+							result[i] = product
+						}
+					}
+					return result, nil
+				},
+			},
+			"productByMlId": &graphql.Field{
+				Type: productType,
+				Args: graphql.FieldConfigArgument{
+					"mlId": &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(graphql.String),
+					},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					mlId, _ := p.Args["mlId"].(string)
+
+					data, err := products.FindByMlID(p.Context, mlId, selectedFields(p.Info)...)
+					if err != nil {
+						if errors.Is(err, sql.ErrNoRows) {
+							return nil, nil
+						}
+						return nil, resolverError(p.Context, err)
+					}
+					return data, nil
+				},
+			},
+		},
+	})
+
+	// mlId, merchantId and name are mandatory, every other field is stored as NULL when left out
+	var productInputType = graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "ProductInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"mlId": &graphql.InputObjectFieldConfig{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			"merchantId": &graphql.InputObjectFieldConfig{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			"name": &graphql.InputObjectFieldConfig{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			"longDesc":  &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"shortDesc": &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"icon":      &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"quota":     &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"startPeriod": &graphql.InputObjectFieldConfig{
+				Type:        graphql.String,
+				Description: "Given together with endPeriod or not at all",
+			},
+			"endPeriod": &graphql.InputObjectFieldConfig{
+				Type:        graphql.String,
+				Description: "Given together with startPeriod or not at all",
+			},
+			"metadata": &graphql.InputObjectFieldConfig{
+				Type:        jsonScalar,
+				Description: "Must be a JSON object",
+			},
+		},
+	})
+
+	// every field but mlId is optional, fields left out keep their stored value on update
+	var productUpsertInputType = graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "ProductUpsertInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"mlId": &graphql.InputObjectFieldConfig{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			"merchantId":  &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"name":        &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"longDesc":    &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"shortDesc":   &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"icon":        &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"quota":       &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"startPeriod": &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"endPeriod":   &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"metadata":    &graphql.InputObjectFieldConfig{Type: jsonScalar},
+		},
+	})
+
+	var upsertProductPayloadType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "UpsertProductPayload",
+		Fields: graphql.Fields{
+			"product": &graphql.Field{Type: productType},
+			"created": &graphql.Field{Type: graphql.Boolean},
+		},
+	})
+
+	var rootMutation = graphql.NewObject(graphql.ObjectConfig{
+		Name: "RootMutation",
+		Fields: graphql.Fields{
+			"createProduct": &graphql.Field{
+				Type: productType,
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(productInputType),
+					},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					args, _ := p.Args["input"].(map[string]interface{})
+					input, err := productInput(args, false)
+					if err != nil {
+						return nil, resolverError(p.Context, err)
+					}
+
+					// the authenticated merchant always wins over the client supplied merchantId
+					if err := auth.Authorize(p.Context, input.MerchantId.String); err != nil {
+						return nil, resolverError(p.Context, err)
+					}
+					if authMerchantId, ok := auth.MerchantFromContext(p.Context); ok {
+						input.MerchantId = sql.NullString{String: authMerchantId, Valid: true}
+					}
+
+					data, err := products.Create(p.Context, input, selectedFields(p.Info)...)
+					if err != nil {
+						return nil, resolverError(p.Context, err)
+					}
+					return data, nil
+				},
+			},
+			"upsertProduct": &graphql.Field{
+				Type:        upsertProductPayloadType,
+				Description: "Create the product or update the one with the same mlId",
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(productUpsertInputType),
+					},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					args, _ := p.Args["input"].(map[string]interface{})
+					input, err := productInput(args, true)
+					if err != nil {
+						return nil, resolverError(p.Context, err)
+					}
+
+					if input.MerchantId.Valid {
+						if err := auth.Authorize(p.Context, input.MerchantId.String); err != nil {
+							return nil, resolverError(p.Context, err)
+						}
+					}
+					if authMerchantId, ok := auth.MerchantFromContext(p.Context); ok {
+						input.MerchantId = sql.NullString{String: authMerchantId, Valid: true}
+					}
+
+					data, created, err := products.Upsert(p.Context, input)
+					if err != nil {
+						return nil, resolverError(p.Context, err)
+					}
+					forgetProduct(p.Context, data.Id)
+					return map[string]interface{}{
+						"product": data,
+						"created": created,
+					}, nil
+				},
+			},
+			"deleteProduct": &graphql.Field{
+				Type: graphql.Boolean,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(graphql.Int),
+					},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					id, _ := p.Args["id"].(int)
+
+					// the owner is checked by Delete against the row it locks
+					if err := products.Delete(p.Context, id); err != nil {
+						return nil, resolverError(p.Context, err)
+					}
+					forgetProduct(p.Context, id)
+					return true, nil
+				},
+			},
+			"setProductQuota": &graphql.Field{
+				Type:        productType,
+				Description: "Change only the quota of a product, every other field is left as is",
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(graphql.Int),
+					},
+					"quota": &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(graphql.Int),
+					},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					id, _ := p.Args["id"].(int)
+					quota, _ := p.Args["quota"].(int)
+
+					invalid := fieldErrors{}
+					invalid.add("quota", validateQuota(quota))
+					if err := invalid.err(); err != nil {
+						return nil, resolverError(p.Context, err)
+					}
+
+					data, err := products.SetQuota(p.Context, id, quota)
+					if err != nil {
+						return nil, resolverError(p.Context, err)
+					}
+					forgetProduct(p.Context, id)
+					return data, nil
+				},
+			},
+			"restoreProduct": &graphql.Field{
+				Type: productType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(graphql.Int),
+					},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					id, _ := p.Args["id"].(int)
+
+					data, err := products.Restore(p.Context, id)
+					if err != nil {
+						return nil, resolverError(p.Context, err)
+					}
+					forgetProduct(p.Context, id)
+					return data, nil
+				},
+			},
+		},
+	})
+
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query:    rootQuery,
+		Mutation: rootMutation,
+	})
+	if err != nil {
+		return schema, err
+	}
+
+	recoverResolvers(schema)
+	return schema, nil
+}
@@ -0,0 +1,250 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"test-sql/apperror"
+	"test-sql/auth"
+	"test-sql/db"
+	"testing"
+	"time"
+
+	"github.com/graphql-go/graphql"
+)
+
+func TestProductsTotalPages(t *testing.T) {
+	tests := []struct {
+		name           string
+		products       int
+		limit          int
+		wantTotalData  int
+		wantTotalPages int
+	}{
+		{name: "empty table", products: 0, limit: 10, wantTotalData: 0, wantTotalPages: 0},
+		{name: "fewer rows than the limit", products: 3, limit: 10, wantTotalData: 3, wantTotalPages: 1},
+		{name: "exactly one page", products: 10, limit: 10, wantTotalData: 10, wantTotalPages: 1},
+		{name: "partial last page", products: 11, limit: 5, wantTotalData: 11, wantTotalPages: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var products []*db.ListEntity
+			for i := 1; i <= tt.products; i++ {
+				products = append(products, fakeProduct(i, "M001"))
+			}
+
+			result := execute(t, context.Background(), newFakeProducts(products...), nil,
+				`query($limit: Int) { products(limit: $limit) { totalData totalPages } }`,
+				map[string]interface{}{"limit": tt.limit})
+
+			var data struct {
+				Products struct {
+					TotalData  int
+					TotalPages int
+				}
+			}
+			decode(t, result, &data)
+
+			if data.Products.TotalData != tt.wantTotalData || data.Products.TotalPages != tt.wantTotalPages {
+				t.Errorf("totalData %d totalPages %d, want %d and %d", data.Products.TotalData, data.Products.TotalPages, tt.wantTotalData, tt.wantTotalPages)
+			}
+		})
+	}
+}
+
+func TestCalcTotalPages(t *testing.T) {
+	tests := []struct {
+		total int64
+		limit int
+		want  int
+	}{
+		{total: 0, limit: 10, want: 0},
+		{total: 5, limit: 0, want: 0},
+		{total: 5, limit: -1, want: 0},
+		{total: 1, limit: 10, want: 1},
+		{total: 21, limit: 10, want: 3},
+	}
+
+	for _, tt := range tests {
+		if got := calcTotalPages(tt.total, tt.limit); got != tt.want {
+			t.Errorf("calcTotalPages(%d, %d) = %d, want %d", tt.total, tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestResolvers(t *testing.T) {
+	tests := []struct {
+		name      string
+		merchant  string
+		apiKeys   string
+		query     string
+		wantData  string
+		wantCode  string
+		wantCalls map[string]int
+	}{
+		{
+			name:      "products list",
+			query:     `{ products(limit: 2) { data { id name } totalData } }`,
+			wantData:  `{"products":{"data":[{"id":1,"name":"Product 1"},{"id":2,"name":"Product 2"}],"totalData":3}}`,
+			wantCalls: map[string]int{"ListWithTotal": 1},
+		},
+		{
+			name:      "products count",
+			query:     `{ productsCount(merchantId: "M002") }`,
+			wantData:  `{"productsCount":1}`,
+			wantCalls: map[string]int{"Count": 1},
+		},
+		{
+			name:      "product by id",
+			query:     `{ product(id: 2) { id mlId merchantId } }`,
+			wantData:  `{"product":{"id":2,"merchantId":"M001","mlId":"ML-2"}}`,
+			wantCalls: map[string]int{"FindByID": 1},
+		},
+		{
+			name:      "missing product",
+			query:     `{ product(id: 99) { id } }`,
+			wantCode:  apperror.CodeNotFound,
+			wantCalls: map[string]int{"FindByID": 1},
+		},
+		{
+			name:      "create product",
+			merchant:  "M001",
+			query:     `mutation { createProduct(input: {mlId: "ML-NEW", merchantId: "M001", name: "New"}) { id mlId name } }`,
+			wantData:  `{"createProduct":{"id":4,"mlId":"ML-NEW","name":"New"}}`,
+			wantCalls: map[string]int{"Create": 1},
+		},
+		{
+			name:      "create product anonymously",
+			apiKeys:   "key-one:M001",
+			query:     `mutation { createProduct(input: {mlId: "ML-NEW", merchantId: "M001", name: "New"}) { id } }`,
+			wantCode:  apperror.CodeUnauthenticated,
+			wantCalls: map[string]int{"Create": 0},
+		},
+		{
+			name:      "create product anonymously without api keys",
+			query:     `mutation { createProduct(input: {mlId: "ML-NEW", merchantId: "M001", name: "New"}) { id } }`,
+			wantData:  `{"createProduct":{"id":4}}`,
+			wantCalls: map[string]int{"Create": 1},
+		},
+		{
+			name:      "create product with an invalid input",
+			merchant:  "M001",
+			query:     `mutation { createProduct(input: {mlId: " ", merchantId: "M001", name: "New"}) { id } }`,
+			wantCode:  apperror.CodeValidation,
+			wantCalls: map[string]int{"Create": 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("API_KEYS", tt.apiKeys)
+			products := newFakeProducts(fakeProduct(1, "M001"), fakeProduct(2, "M001"), fakeProduct(3, "M002"))
+
+			ctx := context.Background()
+			if tt.merchant != "" {
+				ctx = auth.WithMerchant(ctx, tt.merchant)
+			}
+			result := execute(t, ctx, products, nil, tt.query, nil)
+
+			if code := errorCode(result); code != tt.wantCode {
+				t.Fatalf("error code = %q, want %q (errors %v)", code, tt.wantCode, result.Errors)
+			}
+			if tt.wantData != "" {
+				data, err := json.Marshal(result.Data)
+				if err != nil {
+					t.Fatalf("encode data: %v", err)
+				}
+				if string(data) != tt.wantData {
+					t.Errorf("data = %s, want %s", data, tt.wantData)
+				}
+			}
+			for method, want := range tt.wantCalls {
+				if got := products.callCount(method); got != want {
+					t.Errorf("%s called %d times, want %d", method, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestResolverStopsWithTheContext(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		cancel  bool
+		wantErr error
+	}{
+		{name: "client gone", timeout: time.Minute, cancel: true, wantErr: context.Canceled},
+		{name: "request deadline", timeout: 50 * time.Millisecond, wantErr: context.DeadlineExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			products := &blockingProducts{started: make(chan struct{})}
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+			go func() {
+				<-products.started
+				if tt.cancel {
+					cancel()
+				}
+			}()
+
+			done := make(chan *graphql.Result, 1)
+			go func() {
+				done <- execute(t, ctx, products, nil, `{ products { data { id } } }`, nil)
+			}()
+
+			select {
+			case result := <-done:
+				if !result.HasErrors() || result.Errors[0].Message != tt.wantErr.Error() {
+					t.Errorf("errors = %v, want %v", result.Errors, tt.wantErr)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("resolver still running a second after the context was done")
+			}
+		})
+	}
+}
+
+func TestProductsPageFlags(t *testing.T) {
+	tests := []struct {
+		name     string
+		products int
+		page     int
+		wantNext bool
+		wantPrev bool
+	}{
+		{name: "first page", products: 25, page: 1, wantNext: true, wantPrev: false},
+		{name: "middle page", products: 25, page: 2, wantNext: true, wantPrev: true},
+		{name: "last page", products: 25, page: 3, wantNext: false, wantPrev: true},
+		{name: "single page", products: 4, page: 1, wantNext: false, wantPrev: false},
+		{name: "empty result", products: 0, page: 1, wantNext: false, wantPrev: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var products []*db.ListEntity
+			for i := 1; i <= tt.products; i++ {
+				products = append(products, fakeProduct(i, "M001"))
+			}
+
+			result := execute(t, context.Background(), newFakeProducts(products...), nil,
+				`query($page: Int) { products(page: $page, limit: 10) { hasNextPage hasPrevPage } }`,
+				map[string]interface{}{"page": tt.page})
+
+			var data struct {
+				Products struct {
+					HasNextPage bool
+					HasPrevPage bool
+				}
+			}
+			decode(t, result, &data)
+
+			if data.Products.HasNextPage != tt.wantNext || data.Products.HasPrevPage != tt.wantPrev {
+				t.Errorf("hasNextPage %t hasPrevPage %t, want %t and %t", data.Products.HasNextPage, data.Products.HasPrevPage, tt.wantNext, tt.wantPrev)
+			}
+		})
+	}
+}
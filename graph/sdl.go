@@ -0,0 +1,143 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+)
+
+// builtinScalars are part of every schema and left out of the printed SDL
+var builtinScalars = map[string]bool{
+	"String":  true,
+	"Int":     true,
+	"Float":   true,
+	"Boolean": true,
+	"ID":      true,
+}
+
+// PrintSchema render the schema as SDL for codegen tooling, graphql-go has no printer for a
+// built schema. Types and fields are sorted by name so the output is stable between runs.
+func PrintSchema(schema graphql.Schema) string {
+	var sb strings.Builder
+
+	sb.WriteString("schema {\n")
+	if query := schema.QueryType(); query != nil {
+		fmt.Fprintf(&sb, "  query: %s\n", query.Name())
+	}
+	if mutation := schema.MutationType(); mutation != nil {
+		fmt.Fprintf(&sb, "  mutation: %s\n", mutation.Name())
+	}
+	sb.WriteString("}\n")
+
+	typeMap := schema.TypeMap()
+	names := make([]string, 0, len(typeMap))
+	for name := range typeMap {
+		if strings.HasPrefix(name, "__") || builtinScalars[name] {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		sb.WriteString("\n")
+		switch named := typeMap[name].(type) {
+		case *graphql.Object:
+			printDescription(&sb, "", named.Description())
+			fmt.Fprintf(&sb, "type %s {\n", named.Name())
+			fields := named.Fields()
+			for _, fieldName := range sortedKeys(fields) {
+				printField(&sb, fields[fieldName])
+			}
+			sb.WriteString("}\n")
+		case *graphql.InputObject:
+			printDescription(&sb, "", named.Description())
+			fmt.Fprintf(&sb, "input %s {\n", named.Name())
+			fields := named.Fields()
+			for _, fieldName := range sortedKeys(fields) {
+				field := fields[fieldName]
+				printDescription(&sb, "  ", field.Description())
+				fmt.Fprintf(&sb, "  %s: %s%s\n", field.Name(), field.Type.Name(), printDefault(field.Type, field.DefaultValue))
+			}
+			sb.WriteString("}\n")
+		case *graphql.Enum:
+			printDescription(&sb, "", named.Description())
+			fmt.Fprintf(&sb, "enum %s {\n", named.Name())
+			for _, value := range named.Values() {
+				printDescription(&sb, "  ", value.Description)
+				fmt.Fprintf(&sb, "  %s%s\n", value.Name, printDeprecated(value.DeprecationReason))
+			}
+			sb.WriteString("}\n")
+		case *graphql.Scalar:
+			printDescription(&sb, "", named.Description())
+			fmt.Fprintf(&sb, "scalar %s\n", named.Name())
+		}
+	}
+
+	return sb.String()
+}
+
+func printField(sb *strings.Builder, field *graphql.FieldDefinition) {
+	printDescription(sb, "  ", field.Description)
+	sb.WriteString("  " + field.Name)
+
+	if len(field.Args) > 0 {
+		args := append([]*graphql.Argument(nil), field.Args...)
+		sort.Slice(args, func(i, j int) bool { return args[i].Name() < args[j].Name() })
+
+		parts := make([]string, 0, len(args))
+		for _, arg := range args {
+			parts = append(parts, fmt.Sprintf("%s: %s%s", arg.Name(), arg.Type.Name(), printDefault(arg.Type, arg.DefaultValue)))
+		}
+		sb.WriteString("(" + strings.Join(parts, ", ") + ")")
+	}
+
+	fmt.Fprintf(sb, ": %s%s\n", field.Type.Name(), printDeprecated(field.DeprecationReason))
+}
+
+// printDefault render a default value as a literal, enum defaults are stored as their internal
+// value so they are mapped back to the value name
+func printDefault(inputType graphql.Input, value interface{}) string {
+	if value == nil {
+		return ""
+	}
+
+	if enum, ok := inputType.(*graphql.Enum); ok {
+		for _, enumValue := range enum.Values() {
+			if enumValue.Value == value {
+				return " = " + enumValue.Name
+			}
+		}
+	}
+
+	if text, ok := value.(string); ok {
+		return " = " + strconv.Quote(text)
+	}
+	return fmt.Sprintf(" = %v", value)
+}
+
+func printDeprecated(reason string) string {
+	if reason == "" {
+		return ""
+	}
+	return fmt.Sprintf(" @deprecated(reason: %s)", strconv.Quote(reason))
+}
+
+func printDescription(sb *strings.Builder, indent string, description string) {
+	if description == "" {
+		return
+	}
+	fmt.Fprintf(sb, "%s%s\n", indent, strconv.Quote(description))
+}
+
+func sortedKeys[V any](fields map[string]V) []string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
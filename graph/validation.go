@@ -0,0 +1,257 @@
+package graph
+
+import (
+	"strconv"
+	"test-sql/apperror"
+	"test-sql/dotenv"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// validateRequest run the pre-execution guards against the parsed operation and return its
+// estimated cost, logged for every request even when GRAPHQL_MAX_COST is 0 and not enforced.
+// Parse errors are left to graphql.Do so the client gets the usual syntax error.
+func validateRequest(params Request, pagination PaginationConfig) (int, error) {
+	doc, err := parseQuery(params.Query)
+	if err != nil {
+		return 0, nil
+	}
+
+	operation := findOperation(doc, params.OperationName)
+	if operation == nil {
+		return 0, nil
+	}
+
+	fragments := collectFragments(doc)
+	cost := selectionCost(operation.SelectionSet, fragments, params.Variables, pagination, map[string]bool{})
+
+	if !dotenv.GetBool("GRAPHQL_INTROSPECTION", true) && usesIntrospection(operation.SelectionSet, fragments, map[string]bool{}) {
+		return cost, apperror.Validationf("introspection is disabled")
+	}
+
+	// aliases let one operation repeat an expensive root field, depth does not catch that
+	if maxRootFields := dotenv.GetInt("GRAPHQL_MAX_ROOT_FIELDS", 10); maxRootFields > 0 {
+		if count := rootFieldCount(operation.SelectionSet, fragments, map[string]bool{}); count > maxRootFields {
+			return cost, apperror.Validationf("query selects %d root fields, the maximum is %d", count, maxRootFields)
+		}
+	}
+
+	maxDepth := dotenv.GetInt("GRAPHQL_MAX_DEPTH", 10)
+	if depth := selectionDepth(operation.SelectionSet, fragments, map[string]bool{}); depth > maxDepth {
+		return cost, apperror.Validationf("query depth %d exceeds the maximum of %d", depth, maxDepth)
+	}
+
+	if maxCost := dotenv.GetInt("GRAPHQL_MAX_COST", 500); maxCost > 0 && cost > maxCost {
+		return cost, apperror.Validationf("query cost %d exceeds the maximum of %d", cost, maxCost)
+	}
+
+	return cost, nil
+}
+
+// collectFragments index the fragment definitions of a document by name
+func collectFragments(doc *ast.Document) map[string]*ast.FragmentDefinition {
+	fragments := map[string]*ast.FragmentDefinition{}
+	for _, definition := range doc.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok && fragment.Name != nil {
+			fragments[fragment.Name.Value] = fragment
+		}
+	}
+
+	return fragments
+}
+
+// usesIntrospection report whether a selection set asks for __schema or __type, __typename
+// stays allowed since clients rely on it for union and interface results
+func usesIntrospection(selectionSet *ast.SelectionSet, fragments map[string]*ast.FragmentDefinition, visited map[string]bool) bool {
+	if selectionSet == nil {
+		return false
+	}
+
+	for _, selection := range selectionSet.Selections {
+		switch node := selection.(type) {
+		case *ast.Field:
+			if name := node.Name.Value; name == "__schema" || name == "__type" {
+				return true
+			}
+			if usesIntrospection(node.SelectionSet, fragments, visited) {
+				return true
+			}
+		case *ast.InlineFragment:
+			if usesIntrospection(node.SelectionSet, fragments, visited) {
+				return true
+			}
+		case *ast.FragmentSpread:
+			name := node.Name.Value
+			fragment, ok := fragments[name]
+			if !ok || visited[name] {
+				continue
+			}
+			visited[name] = true
+			if usesIntrospection(fragment.SelectionSet, fragments, visited) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// selectionDepth count the deepest field nesting of a selection set, fragments don't add a level
+func selectionDepth(selectionSet *ast.SelectionSet, fragments map[string]*ast.FragmentDefinition, visited map[string]bool) int {
+	if selectionSet == nil {
+		return 0
+	}
+
+	depth := 0
+	for _, selection := range selectionSet.Selections {
+		var current int
+		switch node := selection.(type) {
+		case *ast.Field:
+			current = 1 + selectionDepth(node.SelectionSet, fragments, visited)
+		case *ast.InlineFragment:
+			current = selectionDepth(node.SelectionSet, fragments, visited)
+		case *ast.FragmentSpread:
+			name := node.Name.Value
+			fragment, ok := fragments[name]
+			if !ok || visited[name] {
+				continue
+			}
+			visited[name] = true
+			current = selectionDepth(fragment.SelectionSet, fragments, visited)
+			delete(visited, name)
+		}
+
+		depth = max(depth, current)
+	}
+
+	return depth
+}
+
+// rootFieldCount count the fields selected at the root of an operation, every alias once and
+// fragments expanded, __typename is free
+func rootFieldCount(selectionSet *ast.SelectionSet, fragments map[string]*ast.FragmentDefinition, visited map[string]bool) int {
+	if selectionSet == nil {
+		return 0
+	}
+
+	count := 0
+	for _, selection := range selectionSet.Selections {
+		switch node := selection.(type) {
+		case *ast.Field:
+			if node.Name.Value != "__typename" {
+				count++
+			}
+		case *ast.InlineFragment:
+			count += rootFieldCount(node.SelectionSet, fragments, visited)
+		case *ast.FragmentSpread:
+			name := node.Name.Value
+			fragment, ok := fragments[name]
+			if !ok || visited[name] {
+				continue
+			}
+			visited[name] = true
+			count += rootFieldCount(fragment.SelectionSet, fragments, visited)
+			delete(visited, name)
+		}
+	}
+
+	return count
+}
+
+// paginatedFields fields returning a page of rows mapped to their page size arguments, their
+// children are multiplied by the page size
+var paginatedFields = map[string][]string{
+	"products":           {"limit"},
+	"merchants":          {"limit"},
+	"productsConnection": {"first", "last"},
+}
+
+// batchFields fields fetching one row per id, their children are multiplied by the ids given
+var batchFields = map[string]string{
+	"productsByIds": "ids",
+}
+
+// selectionCost estimate the cost of a selection set, every field costs 1 and the children of
+// a paginated field are counted once per row of the requested limit, those of a batch field once
+// per requested id
+func selectionCost(selectionSet *ast.SelectionSet, fragments map[string]*ast.FragmentDefinition, variables map[string]interface{}, pagination PaginationConfig, visited map[string]bool) int {
+	if selectionSet == nil {
+		return 0
+	}
+
+	cost := 0
+	for _, selection := range selectionSet.Selections {
+		switch node := selection.(type) {
+		case *ast.Field:
+			multiplier := 1
+			if arguments, ok := paginatedFields[node.Name.Value]; ok {
+				multiplier = pagination.DefaultLimit
+				for _, argument := range arguments {
+					if limit, ok := argumentInt(node, argument, variables); ok && limit > 0 {
+						multiplier = min(limit, pagination.MaxLimit)
+					}
+				}
+			}
+			if argument, ok := batchFields[node.Name.Value]; ok {
+				multiplier = min(max(argumentLen(node, argument, variables), 1), maxIds)
+			}
+			cost += 1 + multiplier*selectionCost(node.SelectionSet, fragments, variables, pagination, visited)
+		case *ast.InlineFragment:
+			cost += selectionCost(node.SelectionSet, fragments, variables, pagination, visited)
+		case *ast.FragmentSpread:
+			name := node.Name.Value
+			fragment, ok := fragments[name]
+			if !ok || visited[name] {
+				continue
+			}
+			visited[name] = true
+			cost += selectionCost(fragment.SelectionSet, fragments, variables, pagination, visited)
+			delete(visited, name)
+		}
+	}
+
+	return cost
+}
+
+// argumentLen count the items of a list argument of a field, given either inline or as a variable
+func argumentLen(field *ast.Field, name string, variables map[string]interface{}) int {
+	for _, argument := range field.Arguments {
+		if argument.Name == nil || argument.Name.Value != name {
+			continue
+		}
+
+		switch value := argument.Value.(type) {
+		case *ast.ListValue:
+			return len(value.Values)
+		case *ast.Variable:
+			list, _ := variables[value.Name.Value].([]interface{})
+			return len(list)
+		}
+	}
+
+	return 0
+}
+
+// argumentInt read an integer argument of a field, given either inline or as a variable
+func argumentInt(field *ast.Field, name string, variables map[string]interface{}) (int, bool) {
+	for _, argument := range field.Arguments {
+		if argument.Name == nil || argument.Name.Value != name {
+			continue
+		}
+
+		switch value := argument.Value.(type) {
+		case *ast.IntValue:
+			parsed, err := strconv.Atoi(value.Value)
+			return parsed, err == nil
+		case *ast.Variable:
+			switch variable := variables[value.Name.Value].(type) {
+			case int:
+				return variable, true
+			case float64:
+				return int(variable), true
+			}
+		}
+	}
+
+	return 0, false
+}
@@ -0,0 +1,198 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"test-sql/apperror"
+	"testing"
+)
+
+// testPagination the pagination defaults the validation tests run with
+var testPagination = PaginationConfig{DefaultLimit: 10, MaxLimit: 100, DefaultPage: 1}
+
+// assertValidation check err is nil when want is empty, else a VALIDATION error containing want
+func assertValidation(t *testing.T, err error, want string) {
+	t.Helper()
+
+	if want == "" {
+		if err != nil {
+			t.Fatalf("validateRequest() error = %v, want none", err)
+		}
+		return
+	}
+
+	var appErr *apperror.Error
+	if !errors.As(err, &appErr) || appErr.Code != apperror.CodeValidation {
+		t.Fatalf("validateRequest() error = %v, want a VALIDATION error", err)
+	}
+	if !strings.Contains(appErr.Message, want) {
+		t.Errorf("validateRequest() error = %q, want it to mention %q", appErr.Message, want)
+	}
+}
+
+func TestValidateRequest(t *testing.T) {
+	tests := []struct {
+		name          string
+		request       Request
+		introspection string
+		maxDepth      string
+		want          string
+	}{
+		{
+			name:    "simple query",
+			request: Request{Query: "{ products { data { id name } } }"},
+		},
+		{
+			name:    "syntax errors are left to the executor",
+			request: Request{Query: "{ products {"},
+		},
+		{
+			name:     "too deep",
+			request:  Request{Query: "{ products { data { merchant { id } } } }"},
+			maxDepth: "3",
+			want:     "query depth 4 exceeds the maximum of 3",
+		},
+		{
+			name:     "depth through a fragment",
+			request:  Request{Query: "query { products { ...Page } } fragment Page on ProductPagination { data { merchant { id } } }"},
+			maxDepth: "3",
+			want:     "query depth 4 exceeds the maximum of 3",
+		},
+		{
+			name:          "introspection disabled",
+			request:       Request{Query: "{ __schema { types { name } } }"},
+			introspection: "false",
+			want:          "introspection is disabled",
+		},
+		{
+			name:          "typename allowed without introspection",
+			request:       Request{Query: "{ __typename }"},
+			introspection: "false",
+		},
+		{
+			name:     "the named operation is checked",
+			request:  Request{Query: "query Small { locale } query Deep { products { data { merchant { id } } } }", OperationName: "Small"},
+			maxDepth: "3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GRAPHQL_INTROSPECTION", tt.introspection)
+			t.Setenv("GRAPHQL_MAX_DEPTH", tt.maxDepth)
+
+			_, err := validateRequest(tt.request, testPagination)
+			assertValidation(t, err, tt.want)
+		})
+	}
+}
+
+func TestQueryCost(t *testing.T) {
+	const page = "query($limit: Int) { products(limit: $limit) { data { id name merchant { id name } } } }"
+
+	tests := []struct {
+		name     string
+		request  Request
+		maxCost  string
+		wantCost int
+		want     string
+	}{
+		{
+			name:     "10 items pass",
+			request:  Request{Query: page, Variables: map[string]interface{}{"limit": 10}},
+			wantCost: 61,
+		},
+		{
+			name:     "100 items are rejected",
+			request:  Request{Query: page, Variables: map[string]interface{}{"limit": 100}},
+			wantCost: 601,
+			want:     "query cost 601 exceeds the maximum of 500",
+		},
+		{
+			name:     "limit as a literal",
+			request:  Request{Query: "{ products(limit: 100) { data { id name merchant { id name } } } }"},
+			wantCost: 601,
+			want:     "query cost 601 exceeds the maximum of 500",
+		},
+		{
+			name:     "limit above the maximum is costed at the maximum",
+			request:  Request{Query: page, Variables: map[string]interface{}{"limit": 1000}},
+			wantCost: 601,
+			want:     "query cost 601 exceeds the maximum of 500",
+		},
+		{
+			name:     "no limit is costed at the default",
+			request:  Request{Query: "{ products { data { id name merchant { id name } } } }"},
+			wantCost: 61,
+		},
+		{
+			name:     "a zero maximum is not enforced",
+			request:  Request{Query: page, Variables: map[string]interface{}{"limit": 100}},
+			maxCost:  "0",
+			wantCost: 601,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GRAPHQL_MAX_COST", tt.maxCost)
+
+			cost, err := validateRequest(tt.request, testPagination)
+			assertValidation(t, err, tt.want)
+			if cost != tt.wantCost {
+				t.Errorf("cost = %d, want %d", cost, tt.wantCost)
+			}
+		})
+	}
+}
+
+// aliasedProducts repeat the products root field n times under distinct aliases
+func aliasedProducts(n int) string {
+	var fields strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&fields, " p%d: products { data { id } }", i)
+	}
+	return fields.String()
+}
+
+func TestMaxRootFields(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         string
+		maxRootFields string
+		want          string
+	}{
+		{name: "at the maximum", query: "{" + aliasedProducts(10) + " }"},
+		{name: "aliased spam", query: "{" + aliasedProducts(11) + " }", want: "query selects 11 root fields, the maximum is 10"},
+		{name: "typename is not counted", query: "{ __typename" + aliasedProducts(10) + " }"},
+		{
+			name:  "spam behind a fragment spread",
+			query: "query { ...Spam } fragment Spam on RootQuery {" + aliasedProducts(11) + " }",
+			want:  "query selects 11 root fields, the maximum is 10",
+		},
+		{
+			name:  "split between fields and a fragment spread",
+			query: "query {" + aliasedProducts(6) + " ...More } fragment More on RootQuery { q1: productsCount q2: productsCount q3: productsCount q4: productsCount q5: productsCount }",
+			want:  "query selects 11 root fields, the maximum is 10",
+		},
+		{
+			name:  "spam in an inline fragment",
+			query: "query { ... on RootQuery {" + aliasedProducts(12) + " } }",
+			want:  "query selects 12 root fields, the maximum is 10",
+		},
+		{name: "lower maximum", query: "{" + aliasedProducts(4) + " }", maxRootFields: "3", want: "query selects 4 root fields, the maximum is 3"},
+		{name: "disabled", query: "{" + aliasedProducts(50) + " }", maxRootFields: "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GRAPHQL_MAX_ROOT_FIELDS", tt.maxRootFields)
+			// the cost limit would also catch the larger queries, keep it out of the way
+			t.Setenv("GRAPHQL_MAX_COST", "0")
+
+			_, err := validateRequest(Request{Query: tt.query}, testPagination)
+			assertValidation(t, err, tt.want)
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"test-sql/db"
+	"time"
+)
+
+// expiredLogLimit cap the products listed per sweep, the total is always logged
+const expiredLogLimit = 100
+
+// RunExpirySweep log the products whose end_period passed since the previous sweep together
+// with the total of expired products, every interval until ctx is cancelled. Nothing is
+// modified, the job only reports.
+func RunExpirySweep(ctx context.Context, products db.ProductRepository, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	since := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			sweepExpired(ctx, products, since, now)
+			since = now
+		}
+	}
+}
+
+func sweepExpired(ctx context.Context, products db.ProductRepository, since time.Time, now time.Time) {
+	expired, err := products.List(ctx, db.Params{
+		Page:      1,
+		Limit:     expiredLogLimit,
+		SortBy:    "endPeriod",
+		SortOrder: "ASC",
+		EndAfter:  &since,
+		EndBefore: &now,
+	})
+	if err != nil {
+		slog.Error("expiry sweep failed", "error", err)
+		return
+	}
+
+	total, err := products.Count(ctx, db.Params{EndBefore: &now})
+	if err != nil {
+		slog.Error("expiry sweep failed", "error", err)
+		return
+	}
+
+	for _, product := range expired {
+		slog.Info("product expired", "id", product.Id, "ml_id", deref(product.MlId), "end_period", deref(product.EndPeriod))
+	}
+	slog.Info("expiry sweep done", "newly_expired", len(expired), "total_expired", total)
+}
+
+func deref(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
@@ -0,0 +1,76 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"test-sql/db"
+	"testing"
+	"time"
+)
+
+// stubProducts answer List and Count from fixed values and record the params they got
+type stubProducts struct {
+	db.ProductRepository
+	list      []*db.ListEntity
+	total     int64
+	err       error
+	listCalls []db.Params
+	counted   bool
+}
+
+func (s *stubProducts) List(_ context.Context, params db.Params) ([]*db.ListEntity, error) {
+	s.listCalls = append(s.listCalls, params)
+	return s.list, s.err
+}
+
+func (s *stubProducts) Count(context.Context, db.Params) (int64, error) {
+	s.counted = true
+	return s.total, nil
+}
+
+func TestSweepExpired(t *testing.T) {
+	since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := since.Add(time.Hour)
+	mlId := "ML-1"
+
+	tests := []struct {
+		name        string
+		products    *stubProducts
+		wantCounted bool
+	}{
+		{
+			name:        "expired products",
+			products:    &stubProducts{list: []*db.ListEntity{{Id: 1, MlId: &mlId}}, total: 5},
+			wantCounted: true,
+		},
+		{
+			name:        "none expired",
+			products:    &stubProducts{},
+			wantCounted: true,
+		},
+		{
+			name:     "list fails",
+			products: &stubProducts{err: errors.New("connection refused")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sweepExpired(context.Background(), tt.products, since, now)
+
+			if len(tt.products.listCalls) != 1 {
+				t.Fatalf("List called %d times, want 1", len(tt.products.listCalls))
+			}
+			params := tt.products.listCalls[0]
+			if !params.EndAfter.Equal(since) || !params.EndBefore.Equal(now) {
+				t.Errorf("listed the window %v to %v, want %v to %v", params.EndAfter, params.EndBefore, since, now)
+			}
+			if params.Limit != expiredLogLimit {
+				t.Errorf("Limit = %d, want %d", params.Limit, expiredLogLimit)
+			}
+			if tt.products.counted != tt.wantCounted {
+				t.Errorf("Count called = %t, want %t", tt.products.counted, tt.wantCounted)
+			}
+		})
+	}
+}
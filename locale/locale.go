@@ -0,0 +1,53 @@
+package locale
+
+import (
+	"context"
+	"test-sql/dotenv"
+
+	"golang.org/x/text/language"
+)
+
+type localeKey struct{}
+
+// multiple the tag "*" parses to, it names no locale in particular
+var multiple = language.MustParse("mul")
+
+// Default the locale used when the request does not ask for one, read from DEFAULT_LOCALE
+// (default "en")
+func Default() string {
+	return dotenv.GetString("DEFAULT_LOCALE", "en")
+}
+
+// Parse pick the preferred locale of an Accept-Language header as a canonical BCP 47 tag, such
+// as "en-US" for "en_us". The default is returned when the header is empty, malformed or only
+// holds "*".
+func Parse(header string) string {
+	tags, _, err := language.ParseAcceptLanguage(header)
+	if err != nil {
+		return Default()
+	}
+
+	for _, tag := range tags {
+		if tag != language.Und && tag != multiple {
+			return tag.String()
+		}
+	}
+
+	return Default()
+}
+
+// WithLocale store the locale resolved for the request in the context
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// FromContext return the locale of the request, the default outside of one
+func FromContext(ctx context.Context) string {
+	if ctx != nil {
+		if locale, ok := ctx.Value(localeKey{}).(string); ok {
+			return locale
+		}
+	}
+
+	return Default()
+}
@@ -0,0 +1,49 @@
+package locale
+
+import (
+	"context"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{name: "empty", header: "", want: "en"},
+		{name: "single", header: "id", want: "id"},
+		{name: "canonical case", header: "en_us", want: "en-US"},
+		{name: "highest quality wins", header: "fr;q=0.5, de-de;q=0.8", want: "de-DE"},
+		{name: "zero quality is ignored", header: "fr;q=0, id", want: "id"},
+		{name: "wildcard", header: "*", want: "en"},
+		{name: "malformed", header: "!!", want: "en"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Parse(tt.header); got != tt.want {
+				t.Errorf("Parse(%q) = %q, want %q", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultFromEnv(t *testing.T) {
+	t.Setenv("DEFAULT_LOCALE", "id")
+
+	if got := Parse(""); got != "id" {
+		t.Errorf("Parse(\"\") = %q, want %q", got, "id")
+	}
+	if got := FromContext(context.Background()); got != "id" {
+		t.Errorf("FromContext() = %q, want %q", got, "id")
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	ctx := WithLocale(context.Background(), "en-GB")
+
+	if got := FromContext(ctx); got != "en-GB" {
+		t.Errorf("FromContext() = %q, want %q", got, "en-GB")
+	}
+}
@@ -0,0 +1,149 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"test-sql/dotenv"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type requestIdKey struct{}
+
+type requestCountersKey struct{}
+
+// requestCounters numbers accumulated while serving a request and logged with it
+type requestCounters struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// New build the json logger, verbosity is read from LOG_LEVEL (debug, info, warn, error). Only
+// one in LOG_DEBUG_SAMPLE_RATE debug lines is written (default 1, every line), info and above
+// are never sampled.
+func New() *slog.Logger {
+	var level slog.Level
+	switch strings.ToLower(dotenv.GetString("LOG_LEVEL", "info")) {
+	case "debug":
+		level = slog.LevelDebug
+	case "warn":
+		level = slog.LevelWarn
+	case "error":
+		level = slog.LevelError
+	default:
+		level = slog.LevelInfo
+	}
+
+	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level})
+	return slog.New(newSamplingHandler(handler, dotenv.GetInt("LOG_DEBUG_SAMPLE_RATE", 1)))
+}
+
+// WithRequestId store the request id so every log line of the request can be correlated
+func WithRequestId(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, requestIdKey{}, requestId)
+}
+
+// WithRequestCounters attach an empty set of counters filled by AddRequestCounter
+func WithRequestCounters(ctx context.Context) context.Context {
+	return context.WithValue(ctx, requestCountersKey{}, &requestCounters{counts: map[string]int{}})
+}
+
+// AddRequestCounter add n to the named counter of the request, nothing happens outside of one
+func AddRequestCounter(ctx context.Context, name string, n int) {
+	counters, _ := ctx.Value(requestCountersKey{}).(*requestCounters)
+	if counters == nil {
+		return
+	}
+
+	counters.mu.Lock()
+	counters.counts[name] += n
+	counters.mu.Unlock()
+}
+
+// RequestCounters return the counters of the request as log attributes, sorted by name
+func RequestCounters(ctx context.Context) []interface{} {
+	counters, _ := ctx.Value(requestCountersKey{}).(*requestCounters)
+	if counters == nil {
+		return nil
+	}
+
+	counters.mu.Lock()
+	defer counters.mu.Unlock()
+
+	names := make([]string, 0, len(counters.counts))
+	for name := range counters.counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	attrs := make([]interface{}, 0, 2*len(names))
+	for _, name := range names {
+		attrs = append(attrs, name, counters.counts[name])
+	}
+	return attrs
+}
+
+// FromContext return the default logger tagged with the request id when there is one
+func FromContext(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if requestId, ok := ctx.Value(requestIdKey{}).(string); ok {
+			return slog.Default().With("request_id", requestId)
+		}
+	}
+
+	return slog.Default()
+}
+
+// Query log a finished db call at debug level and record the row count on its span
+func Query(ctx context.Context, name string, start time.Time, rows int) {
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Int("db.rows", rows))
+
+	FromContext(ctx).DebugContext(ctx, "query executed",
+		"query", name,
+		"duration", time.Since(start),
+		"rows", rows,
+	)
+}
+
+// SlowQuery log a db call that ran past the slow query threshold, with the arguments it was
+// called with so the slow case can be reproduced
+func SlowQuery(ctx context.Context, name string, elapsed time.Duration, args []interface{}) {
+	FromContext(ctx).WarnContext(ctx, "slow query",
+		"query", name,
+		"duration", elapsed,
+		"args", args,
+	)
+}
+
+// QueryTimeout log a db call cut short by its deadline or a cancelled request, with how long it
+// ran before giving up
+func QueryTimeout(ctx context.Context, name string, start time.Time, err error) {
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+
+	FromContext(ctx).WarnContext(ctx, "query timed out",
+		"query", name,
+		"duration", time.Since(start),
+		"error", err,
+	)
+}
+
+// QueryError log a failed db call at error level and mark its span as failed
+func QueryError(ctx context.Context, name string, err error) {
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+
+	FromContext(ctx).ErrorContext(ctx, "query failed",
+		"query", name,
+		"error", err,
+	)
+}
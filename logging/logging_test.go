@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRequestCounters(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		add  map[string][]int
+		want []interface{}
+	}{
+		{
+			name: "outside of a request",
+			ctx:  context.Background(),
+			add:  map[string][]int{"graphql_cost": {3}},
+			want: nil,
+		},
+		{
+			name: "summed and sorted by name",
+			ctx:  WithRequestCounters(context.Background()),
+			add:  map[string][]int{"graphql_cost": {3, 4}, "db_queries": {1}},
+			want: []interface{}{"db_queries", 1, "graphql_cost", 7},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, values := range tt.add {
+				for _, n := range values {
+					AddRequestCounter(tt.ctx, name, n)
+				}
+			}
+
+			if got := RequestCounters(tt.ctx); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RequestCounters() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSamplingHandler(t *testing.T) {
+	tests := []struct {
+		name  string
+		every int
+		level slog.Level
+		want  int
+	}{
+		{name: "debug sampled", every: 4, level: slog.LevelDebug, want: 3},
+		{name: "debug not sampled at rate 1", every: 1, level: slog.LevelDebug, want: 10},
+		{name: "info never sampled", every: 4, level: slog.LevelInfo, want: 10},
+		{name: "warn never sampled", every: 4, level: slog.LevelWarn, want: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			handler := slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug})
+			logger := slog.New(newSamplingHandler(handler, tt.every)).With("component", "test")
+
+			for i := 0; i < 10; i++ {
+				logger.Log(context.Background(), tt.level, "line")
+			}
+
+			if got := strings.Count(out.String(), "msg=line"); got != tt.want {
+				t.Errorf("%d lines written, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+)
+
+// samplingHandler let through one in every records below info, records at info and above
+// always pass. Loggers derived with With share the counter so the rate holds overall.
+type samplingHandler struct {
+	slog.Handler
+	every uint64
+	seen  *atomic.Uint64
+}
+
+func newSamplingHandler(handler slog.Handler, every int) slog.Handler {
+	if every <= 1 {
+		return handler
+	}
+
+	return &samplingHandler{Handler: handler, every: uint64(every), seen: &atomic.Uint64{}}
+}
+
+func (h *samplingHandler) Handle(ctx context.Context, record slog.Record) error {
+	if record.Level < slog.LevelInfo && (h.seen.Add(1)-1)%h.every != 0 {
+		return nil
+	}
+
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *samplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &samplingHandler{Handler: h.Handler.WithAttrs(attrs), every: h.every, seen: h.seen}
+}
+
+func (h *samplingHandler) WithGroup(name string) slog.Handler {
+	return &samplingHandler{Handler: h.Handler.WithGroup(name), every: h.every, seen: h.seen}
+}
@@ -2,511 +2,166 @@ package main
 
 import (
 	"context"
-	"database/sql"
+	"flag"
 	"fmt"
-	"log"
-	"math"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"test-sql/db"
 	"test-sql/dotenv"
+	"test-sql/graph"
+	"test-sql/jobs"
+	"test-sql/logging"
+	"test-sql/seed"
+	"test-sql/server"
+	"test-sql/tracing"
 	"time"
-
-	helmet "github.com/danielkov/gin-helmet"
-	"github.com/gin-contrib/cors"
-	"github.com/gin-contrib/gzip"
-	"github.com/gin-gonic/gin"
-	"github.com/go-sql-driver/mysql"
-	"github.com/graphql-go/graphql"
 )
 
-type ListModel struct {
-	Id          sql.NullInt64
-	MlId        sql.NullString
-	MerchantId  sql.NullString
-	Name        sql.NullString
-	LongDesc    sql.NullString
-	ShortDesc   sql.NullString
-	Icon        sql.NullString
-	Quota       sql.NullString
-	StartPeriod sql.NullString
-	EndPeriod   sql.NullString
-}
-
-type ListEntity struct {
-	Id          int     `json:"id"`
-	MlId        *string `json:"mlId"`
-	MerchantId  *string `json:"merchantId"`
-	Name        *string `json:"name"`
-	LongDesc    *string `json:"longDesc"`
-	ShortDesc   *string `json:"shortDesc"`
-	Icon        *string `json:"icon"`
-	Quota       *string `json:"quota"`
-	StartPeriod *string `json:"startPeriod"`
-	EndPeriod   *string `json:"endPeriod"`
-}
-
-type Params struct {
-	Page  int
-	Limit int
-}
-
 func main() {
-	ctx := context.Background()
-	db, err := connectDatabase()
+	printSchema := flag.Bool("print-schema", false, "print the graphql schema as SDL and exit")
+	seedCount := flag.Int("seed", 0, "insert N fake products for local development and exit")
+	flag.Parse()
 
-	if err != nil {
-		panic(err)
+	// schema printing needs no env or database so codegen can run anywhere
+	if *printSchema {
+		schema, err := graph.NewSchema(nil, nil, graph.LoadPaginationConfig())
+		if err != nil {
+			panic(err)
+		}
+		fmt.Print(graph.PrintSchema(schema))
+		return
 	}
 
-	var productType = graphql.NewObject(graphql.ObjectConfig{
-		Name: "Product",
-		Fields: graphql.Fields{
-			"id": &graphql.Field{Type: graphql.Int},
-			"mlId": &graphql.Field{
-				Type: graphql.String,
-			},
-			"merchantId": &graphql.Field{
-				Type: graphql.String,
-			},
-			"name": &graphql.Field{
-				Type: graphql.String,
-			},
-			"longDesc": &graphql.Field{
-				Type: graphql.String,
-			},
-			"shortDesc": &graphql.Field{
-				Type: graphql.String,
-			},
-			"icon": &graphql.Field{
-				Type: graphql.String,
-			},
-			"quota": &graphql.Field{
-				Type: graphql.String,
-			},
-			"startPeriod": &graphql.Field{
-				Type: graphql.String,
-			},
-			"endPeriod": &graphql.Field{
-				Type: graphql.String,
-			},
-		},
-	})
-
-	var productPaginationType = graphql.NewObject(graphql.ObjectConfig{
-		Name: "ProductPagination",
-		Fields: graphql.Fields{
-			"page":       &graphql.Field{Type: graphql.Int},
-			"limit":      &graphql.Field{Type: graphql.Int},
-			"totalData":  &graphql.Field{Type: graphql.Int},
-			"totalPages": &graphql.Field{Type: graphql.Int},
-			"data":       &graphql.Field{Type: graphql.NewList(productType)},
-		},
-	})
-
-	var rootQuery = graphql.NewObject(graphql.ObjectConfig{
-		Name: "RootQuery",
-		Fields: graphql.Fields{
-			"products": &graphql.Field{
-				Type: productPaginationType,
-				Args: graphql.FieldConfigArgument{
-					"page":  &graphql.ArgumentConfig{Type: graphql.Int},
-					"limit": &graphql.ArgumentConfig{Type: graphql.Int},
-				},
-				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
-					limit := 10
-					page := 1
-
-					if val, ok := p.Args["limit"].(int); ok {
-						limit = val
-					}
-					if val, ok := p.Args["page"].(int); ok && val > 1 {
-						page = val
-					}
-
-					total, err := fetchTotalData(db, ctx)
-					if err != nil {
-						return nil, err
-					}
-
-					d := float64(total) / float64(limit)
-					totalPages := int(math.Ceil(d))
-
-					params := Params{
-						Page:  page,
-						Limit: limit,
-					}
-
-					list, err := fetchList(db, ctx, params)
-					if err != nil {
-						return nil, err
-					}
-					return map[string]interface{}{
-						"data":       list,
-						"page":       page,
-						"limit":      limit,
-						"totalData":  int(total),
-						"totalPages": totalPages,
-					}, nil
-				},
-			},
-			"product": &graphql.Field{
-				Type: productType,
-				Args: graphql.FieldConfigArgument{
-					"id": &graphql.ArgumentConfig{
-						Type: graphql.Int,
-					},
-				},
-				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
-					id, ok := p.Args["id"].(int)
-					if ok {
-						data, err := fetchOne(db, ctx, id)
-						if err != nil {
-							return nil, err
-						}
-						return data, nil
-					}
-					return nil, nil
-				},
-			},
-		},
-	})
-
-	var rootMutation = graphql.NewObject(graphql.ObjectConfig{
-		Name: "RootMutation",
-		Fields: graphql.Fields{
-			"createProduct": &graphql.Field{
-				Type: productType,
-				Args: graphql.FieldConfigArgument{
-					"mlId": &graphql.ArgumentConfig{
-						Type: graphql.NewNonNull(graphql.String),
-					},
-					"merchantId": &graphql.ArgumentConfig{
-						Type: graphql.NewNonNull(graphql.String),
-					},
-					"name": &graphql.ArgumentConfig{
-						Type: graphql.NewNonNull(graphql.String),
-					},
-					"longDesc": &graphql.ArgumentConfig{
-						Type: graphql.NewNonNull(graphql.String),
-					},
-					"shortDesc": &graphql.ArgumentConfig{
-						Type: graphql.NewNonNull(graphql.String),
-					},
-					"icon": &graphql.ArgumentConfig{
-						Type: graphql.NewNonNull(graphql.String),
-					},
-					"quota": &graphql.ArgumentConfig{
-						Type: graphql.NewNonNull(graphql.String),
-					},
-					"startPeriod": &graphql.ArgumentConfig{
-						Type: graphql.NewNonNull(graphql.String),
-					},
-					"endPeriod": &graphql.ArgumentConfig{
-						Type: graphql.NewNonNull(graphql.String),
-					},
-				},
-				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
-					mlId, _ := p.Args["mlId"].(string)
-					merchantId, _ := p.Args["merchantId"].(string)
-					name, _ := p.Args["name"].(string)
-					longDesc, _ := p.Args["longDesc"].(string)
-					shortDesc, _ := p.Args["shortDesc"].(string)
-					icon, _ := p.Args["icon"].(string)
-					quota, _ := p.Args["quota"].(string)
-					startPeriod, _ := p.Args["startPeriod"].(string)
-					endPeriod, _ := p.Args["endPeriod"].(string)
-
-					input := &ListModel{
-						MlId:        sql.NullString{String: mlId, Valid: true},
-						MerchantId:  sql.NullString{String: merchantId, Valid: true},
-						Name:        sql.NullString{String: name, Valid: true},
-						LongDesc:    sql.NullString{String: longDesc, Valid: true},
-						ShortDesc:   sql.NullString{String: shortDesc, Valid: true},
-						Icon:        sql.NullString{String: icon, Valid: true},
-						Quota:       sql.NullString{String: quota, Valid: true},
-						StartPeriod: sql.NullString{String: startPeriod, Valid: true},
-						EndPeriod:   sql.NullString{String: endPeriod, Valid: true},
-					}
+	slog.SetDefault(logging.New())
 
-					data, err := createProduct(db, ctx, input)
-					if err != nil {
-						return nil, err
-					}
-					return data, nil
-				},
-			},
-		},
-	})
-
-	var schema, _ = graphql.NewSchema(graphql.SchemaConfig{
-		Query:    rootQuery,
-		Mutation: rootMutation,
-	})
-
-	// setup router
-	router := gin.Default()
-
-	// Set a lower memory limit for multipart forms (default is 32 MiB)
-	router.MaxMultipartMemory = 10 << 20 // 10 MiB
-
-	// Setup Mode Application
-	if os.Getenv("APP_ENV") == "production" {
-		gin.SetMode(gin.ReleaseMode)
-	} else {
-		gin.SetMode(gin.DebugMode)
+	// a unix socket replaces the host and port
+	required := []string{"DB_USER", "DB_HOST", "DB_PORT", "APP_PORT"}
+	if os.Getenv("DB_SOCKET") != "" {
+		required = []string{"DB_USER", "APP_PORT"}
+	}
+	if missing := dotenv.Missing(required...); len(missing) > 0 {
+		panic(fmt.Errorf("missing required env variables: %s", strings.Join(missing, ", ")))
 	}
 
-	// setup cors origin
-	router.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"*"},
-		AllowHeaders:     []string{"*"},
-		AllowMethods:     []string{"*"},
-		AllowCredentials: true,
-		AllowWildcard:    true,
-		ExposeHeaders:    []string{"Content-Length"},
-	}))
-	router.Use(helmet.Default())
-	router.Use(gzip.Gzip(gzip.BestCompression))
-
-	router.POST("/graphql", func(c *gin.Context) {
-		var params struct {
-			Query string `json:"query"`
-		}
-
-		if err := c.ShouldBindJSON(&params); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		}
-
-		result := graphql.Do(graphql.Params{
-			Schema:        schema,
-			RequestString: params.Query,
-		})
-
-		c.JSON(http.StatusOK, result)
-	})
+	// seeding writes fake rows, never against a production database
+	if *seedCount > 0 && os.Getenv("APP_ENV") == "production" {
+		panic(fmt.Errorf("--seed refuses to run with APP_ENV=production"))
+	}
 
-	// serve http
-	log.Fatal(router.Run(":" + os.Getenv("APP_PORT")))
-}
+	ctx := context.Background()
+	conn, err := db.Connect()
 
-func connectDatabase() (*sql.DB, error) {
-	loc, err := time.LoadLocation("Asia/Jakarta")
 	if err != nil {
-		return nil, err
+		panic(err)
 	}
 
-	conn := mysql.Config{
-		User:                 os.Getenv("DB_USER"),
-		Passwd:               os.Getenv("DB_PASS"),
-		DBName:               os.Getenv("DB_NAME"),
-		Addr:                 fmt.Sprintf("%s:%s", os.Getenv("DB_HOST"), os.Getenv("DB_PORT")),
-		Net:                  "tcp",
-		ParseTime:            true,
-		Loc:                  loc,
-		AllowNativePasswords: true,
-		Timeout:              60 * time.Second,
+	if err = db.WaitForDatabase(ctx, conn); err != nil {
+		panic(err)
 	}
 
-	dsn := conn.FormatDSN()
-	db, err := sql.Open("mysql", dsn)
+	// reads use the replica when DB_READ_HOST is set, the primary otherwise
+	readConn, err := db.ConnectReplica()
 	if err != nil {
-		return nil, err
+		panic(err)
 	}
-
-	db.SetConnMaxLifetime(10 * time.Minute)
-	db.SetConnMaxLifetime(10 * time.Minute)
-	db.SetMaxIdleConns(50)
-	db.SetMaxOpenConns(50)
-
-	return db, nil
-}
-
-func fetchList(db *sql.DB, ctx context.Context, params Params) ([]*ListEntity, error) {
-	now := time.Now()
-	ctx, cancel := context.WithTimeout(ctx, time.Duration(dotenv.GetInt("CONTEXT_TIMEOUT", 5))*time.Second)
-	defer cancel()
-
-	offset := (params.Page - 1) * params.Limit
-	query := "SELECT id, ml_id, merchant_id, name, long_desc, short_desc, icon, quota, start_period, end_period from products p limit ? offset ?"
-
-	var listModel []*ListModel
-	var list []*ListEntity
-	stmt, err := db.Prepare(query)
-	if err != nil {
-		return list, err
+	if readConn != nil {
+		if err = db.WaitForDatabase(ctx, readConn); err != nil {
+			panic(err)
+		}
+	} else {
+		readConn = conn
 	}
 
-	rows, err := stmt.QueryContext(ctx, params.Limit, offset)
+	shutdownTracer, err := tracing.Init(ctx)
 	if err != nil {
-		return list, err
+		panic(err)
 	}
 
-	if rows.Err() != nil {
-		return list, rows.Err()
-	}
+	// fail fast after DB_BREAKER_THRESHOLD consecutive connection failures (default 5, 0 disables)
+	// and probe again after DB_BREAKER_COOLDOWN seconds (default 10)
+	breaker := db.NewBreaker(dotenv.GetInt("DB_BREAKER_THRESHOLD", 5), time.Duration(dotenv.GetInt("DB_BREAKER_COOLDOWN", 10))*time.Second)
 
-	for rows.Next() {
-		var data ListModel
-		err = rows.Scan(
-			&data.Id,
-			&data.MlId,
-			&data.MerchantId,
-			&data.Name,
-			&data.LongDesc,
-			&data.ShortDesc,
-			&data.Icon,
-			&data.Quota,
-			&data.StartPeriod,
-			&data.EndPeriod,
-		)
+	products := db.NewProductRepository(conn, readConn, breaker)
 
-		if err != nil {
-			break
+	if *seedCount > 0 {
+		if err = seed.Products(ctx, products, *seedCount); err != nil {
+			panic(err)
 		}
-
-		listModel = append(listModel, &data)
+		return
 	}
 
+	executor, err := graph.NewExecutor(products, db.NewMerchantRepository(readConn, breaker))
 	if err != nil {
-		return list, err
-	}
-	defer rows.Close()
-
-	for _, item := range listModel {
-		list = append(list, &ListEntity{
-			Id:          int(item.Id.Int64),
-			MlId:        &item.MlId.String,
-			MerchantId:  &item.MerchantId.String,
-			Name:        &item.Name.String,
-			LongDesc:    &item.LongDesc.String,
-			ShortDesc:   &item.ShortDesc.String,
-			Icon:        &item.Icon.String,
-			Quota:       &item.Quota.String,
-			StartPeriod: &item.StartPeriod.String,
-			EndPeriod:   &item.EndPeriod.String,
-		})
+		panic(err)
 	}
 
-	fmt.Println("waktu mulai :", now.Format("2006-01-02 15:04:05"), "waktu selesai:", time.Now().Format("2006-01-02 15:04:05"))
-	return list, nil
-}
-
-func fetchTotalData(db *sql.DB, ctx context.Context) (int64, error) {
-	now := time.Now()
-	ctx, cancel := context.WithTimeout(ctx, time.Duration(dotenv.GetInt("CONTEXT_TIMEOUT", 5))*time.Second)
-	defer cancel()
-
-	query := "SELECT count(id) from products"
+	// background jobs stop with this context on shutdown
+	jobsCtx, stopJobs := context.WithCancel(ctx)
+	defer stopJobs()
 
-	var totalData int64
-	stmt, err := db.Prepare(query)
-	if err != nil {
-		return totalData, err
+	// report expired products every EXPIRY_SWEEP_INTERVAL seconds, 0 disables the sweep
+	if interval := dotenv.GetInt("EXPIRY_SWEEP_INTERVAL", 300); interval > 0 {
+		go jobs.RunExpirySweep(jobsCtx, products, time.Duration(interval)*time.Second)
 	}
 
-	err = stmt.QueryRowContext(ctx).Scan(&totalData)
-
+	router, closeRouter, err := server.NewRouter(ctx, executor, conn, breaker)
 	if err != nil {
-		return totalData, err
+		panic(err)
 	}
-	fmt.Println("Total :", totalData)
-	fmt.Println("waktu mulai :", now.Format("2006-01-02 15:04:05"), "waktu selesai:", time.Now().Format("2006-01-02 15:04:05"))
-	return totalData, nil
-}
-
-func fetchOne(db *sql.DB, ctx context.Context, id int) (*ListEntity, error) {
-	now := time.Now()
-	ctx, cancel := context.WithTimeout(ctx, time.Duration(dotenv.GetInt("CONTEXT_TIMEOUT", 5))*time.Second)
-	defer cancel()
-
-	query := "SELECT id, ml_id, merchant_id, name, long_desc, short_desc, icon, quota, start_period, end_period from products p where p.id = ? limit 1"
-
-	var data ListModel
 
-	stmt, err := db.Prepare(query)
-	if err != nil {
-		return nil, err
+	// serve http, the timeouts are in seconds and keep slow clients from holding connections
+	// open. HTTP_WRITE_TIMEOUT should stay above REQUEST_TIMEOUT so a slow answer still goes out.
+	httpServer := &http.Server{
+		Addr:              ":" + os.Getenv("APP_PORT"),
+		Handler:           router,
+		ReadHeaderTimeout: time.Duration(dotenv.GetInt("HTTP_READ_HEADER_TIMEOUT", 5)) * time.Second,
+		ReadTimeout:       time.Duration(dotenv.GetInt("HTTP_READ_TIMEOUT", 15)) * time.Second,
+		WriteTimeout:      time.Duration(dotenv.GetInt("HTTP_WRITE_TIMEOUT", 30)) * time.Second,
+		IdleTimeout:       time.Duration(dotenv.GetInt("HTTP_IDLE_TIMEOUT", 60)) * time.Second,
 	}
 
-	row := stmt.QueryRowContext(ctx, id)
-	err = row.Scan(
-		&data.Id,
-		&data.MlId,
-		&data.MerchantId,
-		&data.Name,
-		&data.LongDesc,
-		&data.ShortDesc,
-		&data.Icon,
-		&data.Quota,
-		&data.StartPeriod,
-		&data.EndPeriod,
-	)
-
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, sql.ErrNoRows
+	go func() {
+		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			slog.Error("server failed", "error", err)
+			os.Exit(1)
 		}
+	}()
 
-		return nil, err
-	}
-	defer stmt.Close()
-
-	one := &ListEntity{
-		Id:          int(data.Id.Int64),
-		MlId:        &data.MlId.String,
-		MerchantId:  &data.MerchantId.String,
-		Name:        &data.Name.String,
-		LongDesc:    &data.LongDesc.String,
-		ShortDesc:   &data.ShortDesc.String,
-		Icon:        &data.Icon.String,
-		Quota:       &data.Quota.String,
-		StartPeriod: &data.StartPeriod.String,
-		EndPeriod:   &data.EndPeriod.String,
-	}
-
-	fmt.Println("waktu mulai :", now.Format("2006-01-02 15:04:05"), "waktu selesai:", time.Now().Format("2006-01-02 15:04:05"))
-	return one, nil
-}
+	// wait for interrupt signal to gracefully shutdown the server
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	slog.Info("shutting down server")
+	stopJobs()
 
-func createProduct(db *sql.DB, ctx context.Context, input *ListModel) (*ListEntity, error) {
-	now := time.Now()
-	ctx, cancel := context.WithTimeout(ctx, time.Duration(dotenv.GetInt("CONTEXT_TIMEOUT", 5))*time.Second)
+	shutdownCtx, cancel := context.WithTimeout(ctx, time.Duration(dotenv.GetInt("SHUTDOWN_TIMEOUT", 10))*time.Second)
 	defer cancel()
 
-	query := "INSERT INTO products (ml_id, merchant_id, name, long_desc, short_desc, icon, quota, start_period, end_period) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)"
-
-	stmt, err := db.Prepare(query)
-	if err != nil {
-		return nil, err
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		slog.Error("server forced to shutdown", "error", err)
 	}
 
-	res, err := stmt.ExecContext(ctx,
-		input.MlId.String,
-		input.MerchantId.String,
-		input.Name.String,
-		input.LongDesc.String,
-		input.ShortDesc.String,
-		input.Icon.String,
-		input.Quota.String,
-		input.StartPeriod.String,
-		input.EndPeriod.String,
-	)
+	if err := closeRouter(); err != nil {
+		slog.Error("failed to close router resources", "error", err)
+	}
 
-	if err != nil {
-		return nil, err
+	if err := shutdownTracer(shutdownCtx); err != nil {
+		slog.Error("failed to flush traces", "error", err)
 	}
 
-	lastId, err := res.LastInsertId()
-	if err != nil {
-		return nil, err
+	if err := conn.Close(); err != nil {
+		slog.Error("failed to close database", "error", err)
 	}
 
-	one, err := fetchOne(db, ctx, int(lastId))
-	if err != nil {
-		return nil, err
+	if readConn != conn {
+		if err := readConn.Close(); err != nil {
+			slog.Error("failed to close read replica", "error", err)
+		}
 	}
 
-	fmt.Println("waktu mulai :", now.Format("2006-01-02 15:04:05"), "waktu selesai:", time.Now().Format("2006-01-02 15:04:05"))
-	return one, nil
+	slog.Info("server exited")
 }
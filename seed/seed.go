@@ -0,0 +1,58 @@
+package seed
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"strconv"
+	"test-sql/db"
+	"time"
+)
+
+var (
+	adjectives = []string{"Super", "Hemat", "Mega", "Spesial", "Kilat", "Premium", "Gratis", "Extra"}
+	nouns      = []string{"Cashback", "Diskon", "Voucher", "Ongkir", "Promo", "Bonus", "Poin", "Hadiah"}
+	icons      = []string{"https://cdn.example.com/icons/cashback.png", "https://cdn.example.com/icons/discount.png", "https://cdn.example.com/icons/voucher.png"}
+	merchants  = []string{"M001", "M002", "M003", "M004", "M005"}
+)
+
+// Products insert n fake products through the repository so every row gets its audit entry.
+// Periods are spread around now, some already ended and some not started, to exercise the
+// date filters, sorting and pagination locally.
+func Products(ctx context.Context, products db.ProductRepository, n int) error {
+	batch := time.Now().Unix()
+	for i := 0; i < n; i++ {
+		input := fakeProduct(batch, i)
+		if _, err := products.Create(ctx, input); err != nil {
+			return fmt.Errorf("seed product %d: %w", i+1, err)
+		}
+	}
+
+	slog.InfoContext(ctx, "products seeded", "count", n)
+	return nil
+}
+
+// fakeProduct build a random product, the ml id embeds the batch so reruns don't collide
+func fakeProduct(batch int64, i int) *db.ListModel {
+	name := adjectives[rand.IntN(len(adjectives))] + " " + nouns[rand.IntN(len(nouns))] + " " + strconv.Itoa(i+1)
+	start := time.Now().AddDate(0, 0, rand.IntN(120)-60)
+	end := start.AddDate(0, 0, 1+rand.IntN(90))
+
+	return &db.ListModel{
+		MlId:        valid(fmt.Sprintf("SEED-%d-%05d", batch, i+1)),
+		MerchantId:  valid(merchants[rand.IntN(len(merchants))]),
+		Name:        valid(name),
+		LongDesc:    valid("Long description of " + name),
+		ShortDesc:   valid("Short description of " + name),
+		Icon:        valid(icons[rand.IntN(len(icons))]),
+		Quota:       valid(strconv.Itoa(rand.IntN(1000))),
+		StartPeriod: valid(start.Format(db.PeriodLayout)),
+		EndPeriod:   valid(end.Format(db.PeriodLayout)),
+	}
+}
+
+func valid(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: true}
+}
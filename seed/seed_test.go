@@ -0,0 +1,85 @@
+package seed
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"test-sql/db"
+	"testing"
+	"time"
+)
+
+// stubProducts record the products created through it, failing from the failAt'th call on
+type stubProducts struct {
+	db.ProductRepository
+	created []*db.ListModel
+	failAt  int
+}
+
+func (s *stubProducts) Create(_ context.Context, input *db.ListModel, _ ...string) (*db.ListEntity, error) {
+	if s.failAt > 0 && len(s.created)+1 >= s.failAt {
+		return nil, errors.New("insert failed")
+	}
+	s.created = append(s.created, input)
+	return &db.ListEntity{}, nil
+}
+
+func TestProducts(t *testing.T) {
+	tests := []struct {
+		name        string
+		n           int
+		failAt      int
+		wantCreated int
+		wantErr     bool
+	}{
+		{name: "none", n: 0, wantCreated: 0},
+		{name: "some", n: 25, wantCreated: 25},
+		{name: "stops at the first failure", n: 10, failAt: 4, wantCreated: 3, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			products := &stubProducts{failAt: tt.failAt}
+
+			err := Products(context.Background(), products, tt.n)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Products() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if len(products.created) != tt.wantCreated {
+				t.Errorf("%d products created, want %d", len(products.created), tt.wantCreated)
+			}
+		})
+	}
+}
+
+func TestFakeProduct(t *testing.T) {
+	seen := map[string]bool{}
+	for i := 0; i < 50; i++ {
+		product := fakeProduct(1700000000, i)
+
+		if seen[product.MlId.String] {
+			t.Fatalf("ml id %q generated twice", product.MlId.String)
+		}
+		seen[product.MlId.String] = true
+
+		if !strings.HasPrefix(product.MlId.String, "SEED-1700000000-") {
+			t.Errorf("ml id %q does not embed the batch", product.MlId.String)
+		}
+		if !slices.Contains(merchants, product.MerchantId.String) {
+			t.Errorf("merchant %q is not one of the seed merchants", product.MerchantId.String)
+		}
+
+		start, err := time.Parse(db.PeriodLayout, product.StartPeriod.String)
+		if err != nil {
+			t.Fatalf("start period %q: %v", product.StartPeriod.String, err)
+		}
+		end, err := time.Parse(db.PeriodLayout, product.EndPeriod.String)
+		if err != nil {
+			t.Fatalf("end period %q: %v", product.EndPeriod.String, err)
+		}
+		if !end.After(start) {
+			t.Errorf("period %s to %s ends before it starts", product.StartPeriod.String, product.EndPeriod.String)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+)
+
+// abortWithError answer with the graphql errors array so clients parse every failure the same
+// way, whether it happened in the transport or during execution
+func abortWithError(c *gin.Context, status int, code string, message string) {
+	c.AbortWithStatusJSON(status, gin.H{
+		"errors": []gin.H{{
+			"message":    message,
+			"extensions": gin.H{"code": code},
+		}},
+	})
+}
+
+// jsonErrorMessage explain a json decoding failure with the byte offset or field at fault
+// instead of the terse encoding/json message
+func jsonErrorMessage(err error) string {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return fmt.Sprintf("malformed json at byte offset %d: %s", syntaxErr.Offset, syntaxErr.Error())
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		if typeErr.Field == "" {
+			return fmt.Sprintf("expected %s but got a json %s at byte offset %d", jsonKind(typeErr.Type), typeErr.Value, typeErr.Offset)
+		}
+		return fmt.Sprintf("field %q must be %s but got a json %s at byte offset %d", typeErr.Field, jsonKind(typeErr.Type), typeErr.Value, typeErr.Offset)
+	}
+
+	return err.Error()
+}
+
+// jsonKind name the json value expected for a go type
+func jsonKind(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
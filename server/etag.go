@@ -0,0 +1,54 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql"
+)
+
+// writeResultWithETag answer a successful result with a weak ETag hashed from its payload and
+// 304 when the client already holds it. Weak since gzip changes the bytes on the wire.
+func writeResultWithETag(c *gin.Context, result *graphql.Result) {
+	status := resultStatus(result)
+	if status != http.StatusOK || result.HasErrors() {
+		c.JSON(status, result)
+		return
+	}
+
+	body, err := json.Marshal(result)
+	if err != nil {
+		c.JSON(status, result)
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+	c.Header("ETag", etag)
+
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(status, "application/json; charset=utf-8", body)
+}
+
+// etagMatches report whether an If-None-Match header lists the etag, compared weakly
+func etagMatches(header string, etag string) bool {
+	if header == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,28 @@
+package server
+
+import "testing"
+
+func TestEtagMatches(t *testing.T) {
+	const etag = `W/"abc"`
+
+	tests := []struct {
+		name   string
+		header string
+		want   bool
+	}{
+		{name: "no header", header: "", want: false},
+		{name: "same weak etag", header: `W/"abc"`, want: true},
+		{name: "strong form compared weakly", header: `"abc"`, want: true},
+		{name: "in a list", header: `"xyz", W/"abc"`, want: true},
+		{name: "wildcard", header: "*", want: true},
+		{name: "other etag", header: `W/"xyz"`, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := etagMatches(tt.header, etag); got != tt.want {
+				t.Errorf("etagMatches(%q) = %t, want %t", tt.header, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// gzipExcludedPaths probe and scrape endpoints, small and polled often so never compressed
+var gzipExcludedPaths = map[string]bool{
+	"/health":  true,
+	"/ready":   true,
+	"/metrics": true,
+	"/version": true,
+}
+
+// gzipMiddleware compress responses of at least minLength bytes at the given level. The body
+// is buffered until it reaches minLength so small payloads go out as is and skip the cpu cost.
+func gzipMiddleware(level int, minLength int) gin.HandlerFunc {
+	pool := sync.Pool{
+		New: func() interface{} {
+			gz, _ := gzip.NewWriterLevel(nil, level)
+			return gz
+		},
+	}
+
+	return func(c *gin.Context) {
+		if gzipExcludedPaths[c.Request.URL.Path] {
+			c.Next()
+			return
+		}
+
+		// the response depends on Accept-Encoding even when it ends up uncompressed
+		c.Header("Vary", "Accept-Encoding")
+		if !acceptsGzip(c.GetHeader("Accept-Encoding")) {
+			c.Next()
+			return
+		}
+
+		writer := &gzipWriter{ResponseWriter: c.Writer, pool: &pool, minLength: minLength}
+		c.Writer = writer
+		defer func() {
+			writer.finish()
+			c.Writer = writer.ResponseWriter
+		}()
+
+		c.Next()
+	}
+}
+
+// acceptsGzip report whether an Accept-Encoding header allows gzip. An explicit gzip entry
+// wins over "*", and a q of 0 opts out, so "identity" or "gzip;q=0" get a plain body.
+func acceptsGzip(header string) bool {
+	wildcard := false
+	for _, entry := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(entry, ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != "gzip" && coding != "x-gzip" && coding != "*" {
+			continue
+		}
+
+		accepted := qualityOf(params) > 0
+		if coding == "*" {
+			wildcard = accepted
+			continue
+		}
+		return accepted
+	}
+
+	return wildcard
+}
+
+// qualityOf parse the q parameter of an Accept-Encoding entry, 1 when absent or malformed
+func qualityOf(params string) float64 {
+	for _, param := range strings.Split(params, ";") {
+		name, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok || !strings.EqualFold(strings.TrimSpace(name), "q") {
+			continue
+		}
+
+		q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return 1
+		}
+		return q
+	}
+
+	return 1
+}
+
+// gzipWriter hold the body back until it is known whether it is worth compressing
+type gzipWriter struct {
+	gin.ResponseWriter
+	pool      *sync.Pool
+	minLength int
+	buf       bytes.Buffer
+	gz        *gzip.Writer
+	raw       bool
+}
+
+func (w *gzipWriter) Write(data []byte) (int, error) {
+	if w.gz != nil {
+		return w.gz.Write(data)
+	}
+	if w.raw {
+		return w.ResponseWriter.Write(data)
+	}
+
+	w.buf.Write(data)
+	if w.buf.Len() >= w.minLength {
+		if err := w.startGzip(); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+func (w *gzipWriter) WriteString(data string) (int, error) {
+	return w.Write([]byte(data))
+}
+
+// Flush send what is buffered uncompressed, a streaming handler should not wait on the threshold
+func (w *gzipWriter) Flush() {
+	if w.gz != nil {
+		w.gz.Flush()
+	} else if !w.raw {
+		w.writeRaw()
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *gzipWriter) startGzip() error {
+	// a handler that already encoded the body is left alone
+	if w.Header().Get("Content-Encoding") != "" {
+		return w.writeRaw()
+	}
+
+	w.Header().Set("Content-Encoding", "gzip")
+	w.Header().Del("Content-Length")
+
+	w.gz = w.pool.Get().(*gzip.Writer)
+	w.gz.Reset(w.ResponseWriter)
+	_, err := w.gz.Write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+func (w *gzipWriter) writeRaw() error {
+	w.raw = true
+	if w.buf.Len() == 0 {
+		return nil
+	}
+
+	w.Header().Set("Content-Length", strconv.Itoa(w.buf.Len()))
+	_, err := w.ResponseWriter.Write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+// finish close the gzip stream or send the small body as is
+func (w *gzipWriter) finish() {
+	if w.gz != nil {
+		w.gz.Close()
+		w.pool.Put(w.gz)
+		w.gz = nil
+		return
+	}
+
+	if !w.raw && w.buf.Len() > 0 && w.Status() != http.StatusNotModified {
+		w.writeRaw()
+	}
+}
@@ -0,0 +1,162 @@
+package server
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{header: "", want: false},
+		{header: "gzip", want: true},
+		{header: "GZIP", want: true},
+		{header: "x-gzip", want: true},
+		{header: "gzip, deflate, br", want: true},
+		{header: "deflate, br", want: false},
+		{header: "identity", want: false},
+		{header: "gzip;q=0", want: false},
+		{header: "gzip; q=0.0", want: false},
+		{header: "gzip;q=0.5", want: true},
+		{header: "gzip;q=nonsense", want: true},
+		{header: "*", want: true},
+		{header: "*;q=0", want: false},
+		{header: "gzip;q=0, *", want: false},
+		{header: "*;q=0, gzip", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := acceptsGzip(tt.header); got != tt.want {
+				t.Errorf("acceptsGzip(%q) = %t, want %t", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGzipMiddleware(t *testing.T) {
+	const minLength = 1024
+	large := strings.Repeat(`{"id":1,"name":"Promo"}`, 100)
+
+	tests := []struct {
+		name           string
+		path           string
+		acceptEncoding string
+		body           string
+		wantGzip       bool
+		wantVary       bool
+	}{
+		{name: "gzip accepted", path: "/graphql", acceptEncoding: "gzip, deflate, br", body: large, wantGzip: true, wantVary: true},
+		{name: "no header", path: "/graphql", body: large, wantVary: true},
+		{name: "identity only", path: "/graphql", acceptEncoding: "identity", body: large, wantVary: true},
+		{name: "gzip opted out", path: "/graphql", acceptEncoding: "gzip;q=0, deflate", body: large, wantVary: true},
+		{name: "wildcard", path: "/graphql", acceptEncoding: "*", body: large, wantGzip: true, wantVary: true},
+		{name: "below the minimum length", path: "/graphql", acceptEncoding: "gzip", body: `{"data":{}}`, wantVary: true},
+		{name: "health excluded", path: "/health", acceptEncoding: "gzip", body: large},
+		{name: "metrics excluded", path: "/metrics", acceptEncoding: "gzip", body: large},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(gzipMiddleware(gzip.DefaultCompression, minLength))
+			router.GET(tt.path, func(c *gin.Context) { c.String(http.StatusOK, tt.body) })
+
+			request := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.acceptEncoding != "" {
+				request.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			if gzipped := recorder.Header().Get("Content-Encoding") == "gzip"; gzipped != tt.wantGzip {
+				t.Fatalf("Content-Encoding = %q, want gzip %t", recorder.Header().Get("Content-Encoding"), tt.wantGzip)
+			}
+			if vary := recorder.Header().Get("Vary") == "Accept-Encoding"; vary != tt.wantVary {
+				t.Errorf("Vary = %q, want Accept-Encoding %t", recorder.Header().Get("Vary"), tt.wantVary)
+			}
+
+			var body io.Reader = recorder.Body
+			if tt.wantGzip {
+				reader, err := gzip.NewReader(recorder.Body)
+				if err != nil {
+					t.Fatalf("gzip.NewReader() error = %v", err)
+				}
+				body = reader
+			}
+			got, err := io.ReadAll(body)
+			if err != nil {
+				t.Fatalf("read body: %v", err)
+			}
+			if string(got) != tt.body {
+				t.Errorf("body of %d bytes, want the %d bytes written", len(got), len(tt.body))
+			}
+		})
+	}
+}
+
+// BenchmarkGzipMiddleware measure the cpu cost of each GZIP_LEVEL on graphql sized responses,
+// the compressed size is reported next to it to weigh the bandwidth saved
+func BenchmarkGzipMiddleware(b *testing.B) {
+	// keep the route debug lines out of the benchmark output
+	gin.SetMode(gin.TestMode)
+
+	levels := []struct {
+		name  string
+		level int
+	}{
+		{name: "level=1", level: gzip.BestSpeed},
+		{name: "level=default", level: gzip.DefaultCompression},
+		{name: "level=9", level: gzip.BestCompression},
+	}
+	sizes := []struct {
+		name  string
+		bytes int
+	}{
+		{name: "size=1KB", bytes: 1 << 10},
+		{name: "size=32KB", bytes: 32 << 10},
+		{name: "size=512KB", bytes: 512 << 10},
+	}
+
+	// product rows as the products query returns them, ids vary so the payload is not one repeat
+	var rows strings.Builder
+	for i := 0; rows.Len() < sizes[len(sizes)-1].bytes; i++ {
+		fmt.Fprintf(&rows, `{"id":%d,"mlId":"ML-%d","merchantId":"M%03d","name":"Product %d","longDesc":"Long description of product %d"},`, i, i, i%50, i, i)
+	}
+
+	for _, level := range levels {
+		for _, size := range sizes {
+			b.Run(level.name+"/"+size.name, func(b *testing.B) {
+				body := rows.String()[:size.bytes]
+
+				router := gin.New()
+				router.Use(gzipMiddleware(level.level, 1024))
+				router.POST("/graphql", func(c *gin.Context) { c.String(http.StatusOK, body) })
+
+				request := httptest.NewRequest(http.MethodPost, "/graphql", nil)
+				request.Header.Set("Accept-Encoding", "gzip")
+
+				b.SetBytes(int64(size.bytes))
+				b.ReportAllocs()
+				b.ResetTimer()
+
+				compressed := 0
+				for i := 0; i < b.N; i++ {
+					recorder := httptest.NewRecorder()
+					router.ServeHTTP(recorder, request)
+					compressed = recorder.Body.Len()
+				}
+				b.ReportMetric(float64(compressed), "gzip-bytes")
+			})
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"runtime/debug"
+	"test-sql/apperror"
+	"test-sql/auth"
+	"test-sql/locale"
+	"test-sql/logging"
+	"test-sql/tracing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recovery log a panic with its stack and answer with a graphql shaped INTERNAL error so
+// clients always get an errors array instead of an empty 500
+func recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if r := recover(); r != nil {
+				ctx := c.Request.Context()
+				logging.FromContext(ctx).ErrorContext(ctx, "request panicked", "panic", r, "stack", string(debug.Stack()))
+
+				abortWithError(c, http.StatusInternalServerError, apperror.ErrInternal.Code, apperror.ErrInternal.Message)
+			}
+		}()
+
+		c.Next()
+	}
+}
+
+// apiKeyAuth authenticate server to server callers sending X-API-Key, the request then acts
+// as the merchant the key is scoped to. Requests without the header pass through untouched so
+// other mechanisms can still authenticate them, an unknown key is rejected with 401. Each
+// rejection takes a token from the client ip bucket of limiter, nil when rate limiting is off,
+// and turns into a 429 once those run out.
+func apiKeyAuth(keys *auth.APIKeys, limiter rateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("X-API-Key")
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		merchantId, ok := keys.Lookup(key)
+		if !ok {
+			if limiter != nil {
+				delay, err := limiter.Reserve(c.Request.Context(), rateLimitKey(c))
+				if err == nil && delay > 0 {
+					abortRateLimited(c, delay)
+					return
+				}
+			}
+
+			abortWithError(c, http.StatusUnauthorized, apperror.CodeUnauthenticated, "invalid api key")
+			return
+		}
+
+		c.Request = c.Request.WithContext(auth.WithMerchant(c.Request.Context(), merchantId))
+		c.Next()
+	}
+}
+
+// localeMiddleware resolve the locale of the request from Accept-Language so resolvers can
+// read it with locale.FromContext
+func localeMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// the response depends on Accept-Language once descriptions are localized
+		c.Writer.Header().Add("Vary", "Accept-Language")
+
+		ctx := locale.WithLocale(c.Request.Context(), locale.Parse(c.GetHeader("Accept-Language")))
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// requestTimeout bound the whole request by timeout, on top of the per query DB timeout, so a
+// request chaining many queries cannot run unbounded. Handlers check requestTimedOut before
+// answering.
+func requestTimeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// requestTimedOut answer 504 with a TIMEOUT error when the request deadline passed, the
+// partial result is dropped since the resolvers that ran out of time only hold errors
+func requestTimedOut(c *gin.Context) bool {
+	if !errors.Is(c.Request.Context().Err(), context.DeadlineExceeded) {
+		return false
+	}
+
+	abortWithError(c, http.StatusGatewayTimeout, apperror.CodeTimeout, "request timed out")
+	return true
+}
+
+// requestLogger assign a request id, reusing the X-Request-ID header when sent, and log every request
+func requestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		requestId := c.GetHeader("X-Request-ID")
+		if requestId == "" {
+			requestId = newRequestId()
+		}
+		c.Header("X-Request-ID", requestId)
+		ctx := logging.WithRequestCounters(logging.WithRequestId(c.Request.Context(), requestId))
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		// counters such as graphql_cost are added by the handlers, batches sum their operations
+		attrs := []interface{}{
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"status", c.Writer.Status(),
+			"duration", time.Since(start),
+			"client_ip", c.ClientIP(),
+		}
+		logging.FromContext(ctx).Info("request handled", append(attrs, logging.RequestCounters(ctx)...)...)
+	}
+}
+
+func newRequestId() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+
+	return hex.EncodeToString(b)
+}
+
+// tracingMiddleware wrap the whole request in a server span
+func tracingMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, span := tracing.Tracer.Start(c.Request.Context(), c.Request.Method+" "+c.FullPath(),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(c.Request.Method),
+				semconv.HTTPRoute(c.FullPath()),
+			),
+		)
+		defer span.End()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= 500 {
+			span.SetStatus(codes.Error, "")
+		}
+	}
+}
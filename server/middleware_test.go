@@ -0,0 +1,133 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"test-sql/apperror"
+	"test-sql/auth"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+func TestRecovery(t *testing.T) {
+	tests := []struct {
+		name  string
+		panic func()
+	}{
+		{name: "string panic", panic: func() { panic("boom") }},
+		{name: "nil map write", panic: func() {
+			var counts map[string]int
+			counts["boom"]++
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(recovery())
+			router.POST("/graphql", func(*gin.Context) { tt.panic() })
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/graphql", nil))
+
+			if recorder.Code != http.StatusInternalServerError {
+				t.Errorf("status = %d, want %d", recorder.Code, http.StatusInternalServerError)
+			}
+			if contentType := recorder.Header().Get("Content-Type"); contentType != "application/json; charset=utf-8" {
+				t.Errorf("Content-Type = %q, want json", contentType)
+			}
+
+			var response struct {
+				Errors []struct {
+					Message    string `json:"message"`
+					Extensions struct {
+						Code string `json:"code"`
+					} `json:"extensions"`
+				} `json:"errors"`
+			}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+				t.Fatalf("body %s is not valid json: %v", recorder.Body, err)
+			}
+			if len(response.Errors) != 1 || response.Errors[0].Extensions.Code != apperror.CodeInternal {
+				t.Errorf("body %s, want one INTERNAL error", recorder.Body)
+			}
+		})
+	}
+}
+
+func TestAPIKeyAuthRateLimited(t *testing.T) {
+	const valid, invalid = "key-one", "guess"
+
+	tests := []struct {
+		name       string
+		rateLimit  bool
+		keys       []string
+		wantStatus []int
+	}{
+		{
+			name:       "rejected keys spend the ip tokens",
+			rateLimit:  true,
+			keys:       []string{invalid, invalid, invalid},
+			wantStatus: []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests},
+		},
+		{
+			name:       "rejected keys and anonymous calls share the ip bucket",
+			rateLimit:  true,
+			keys:       []string{invalid, "", invalid},
+			wantStatus: []int{http.StatusUnauthorized, http.StatusOK, http.StatusTooManyRequests},
+		},
+		{
+			name:       "a valid key is limited as its merchant",
+			rateLimit:  true,
+			keys:       []string{invalid, invalid, valid},
+			wantStatus: []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusOK},
+		},
+		{
+			name:       "rate limiting off",
+			keys:       []string{invalid, invalid, invalid},
+			wantStatus: []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusUnauthorized},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var limiter rateLimiter
+			if tt.rateLimit {
+				// two tokens per client and none refilled while the test runs
+				limiter = NewIPRateLimiter(ctx, rate.Every(time.Hour), 2, time.Minute)
+			}
+
+			router := gin.New()
+			router.Use(apiKeyAuth(auth.ParseAPIKeys(valid+":M001"), limiter))
+			if limiter != nil {
+				router.Use(rateLimitMiddleware(limiter))
+			}
+			router.POST("/graphql", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			for i, key := range tt.keys {
+				request := httptest.NewRequest(http.MethodPost, "/graphql", nil)
+				request.RemoteAddr = "203.0.113.7:51234"
+				if key != "" {
+					request.Header.Set("X-API-Key", key)
+				}
+				recorder := httptest.NewRecorder()
+				router.ServeHTTP(recorder, request)
+
+				if recorder.Code != tt.wantStatus[i] {
+					t.Errorf("request %d status = %d, want %d", i+1, recorder.Code, tt.wantStatus[i])
+				}
+				if recorder.Code == http.StatusTooManyRequests && recorder.Header().Get("Retry-After") == "" {
+					t.Errorf("request %d rate limited without a Retry-After", i+1)
+				}
+			}
+		})
+	}
+}
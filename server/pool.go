@@ -0,0 +1,39 @@
+package server
+
+import (
+	"database/sql"
+	"sync"
+)
+
+// poolWatcher tell whether a connection pool is saturated: nearly every connection is in use
+// and callers kept queueing for one since the previous check
+type poolWatcher struct {
+	mu        sync.Mutex
+	conn      *sql.DB
+	lastWaits int64
+	// saturation percentage of MaxOpenConns in use from which the pool counts as full
+	saturation int
+	// maxNewWaits waits for a connection tolerated between two checks of a full pool
+	maxNewWaits int64
+}
+
+func newPoolWatcher(conn *sql.DB, saturation int, maxNewWaits int) *poolWatcher {
+	return &poolWatcher{conn: conn, saturation: saturation, maxNewWaits: int64(maxNewWaits)}
+}
+
+// check return the pool stats and whether the pool is saturated. An unlimited pool never is.
+func (w *poolWatcher) check() (sql.DBStats, int64, bool) {
+	stats := w.conn.Stats()
+
+	w.mu.Lock()
+	newWaits := stats.WaitCount - w.lastWaits
+	w.lastWaits = stats.WaitCount
+	w.mu.Unlock()
+
+	if stats.MaxOpenConnections <= 0 {
+		return stats, newWaits, false
+	}
+
+	full := stats.InUse*100 >= stats.MaxOpenConnections*w.saturation
+	return stats, newWaits, full && newWaits > w.maxNewWaits
+}
@@ -0,0 +1,34 @@
+package server
+
+import (
+	"net/http/pprof"
+
+	"github.com/gin-gonic/gin"
+)
+
+// registerPprof mount the net/http/pprof handlers under /debug/pprof. Only wired when
+// PPROF_ENABLED is set since profiles expose internals and cost cpu while running.
+//
+// Grab a 30s cpu profile and open it in the browser with:
+//
+//	go tool pprof -http=:8081 http://localhost:$APP_PORT/debug/pprof/profile?seconds=30
+func registerPprof(router *gin.Engine) {
+	handler := func(c *gin.Context) {
+		switch c.Param("name") {
+		case "/cmdline":
+			pprof.Cmdline(c.Writer, c.Request)
+		case "/profile":
+			pprof.Profile(c.Writer, c.Request)
+		case "/symbol":
+			pprof.Symbol(c.Writer, c.Request)
+		case "/trace":
+			pprof.Trace(c.Writer, c.Request)
+		default:
+			// index and the named profiles (heap, goroutine, block, ...)
+			pprof.Index(c.Writer, c.Request)
+		}
+	}
+
+	router.GET("/debug/pprof/*name", handler)
+	router.POST("/debug/pprof/*name", handler)
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"test-sql/apperror"
+	"test-sql/auth"
+	"test-sql/logging"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+// rateLimiter hand out request tokens per client key, returning how long the caller has to wait
+// when none is available
+type rateLimiter interface {
+	Reserve(ctx context.Context, key string) (time.Duration, error)
+}
+
+// defaultVisitorTTL idle time after which a bucket is dropped when the configured ttl is not
+// positive, the same as the RATE_LIMIT_TTL default
+const defaultVisitorTTL = 3 * time.Minute
+
+// visitorTTL return ttl, or defaultVisitorTTL when it is 0 or negative since a ticker cannot
+// run on it and the buckets would never be dropped
+func visitorTTL(ttl time.Duration) time.Duration {
+	if ttl <= 0 {
+		return defaultVisitorTTL
+	}
+	return ttl
+}
+
+type visitor struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// IPRateLimiter keep one token bucket per client key in memory, idle buckets are swept so the map
+// stays bounded. Each instance counts on its own, see RedisRateLimiter for a shared limit.
+type IPRateLimiter struct {
+	mu       sync.Mutex
+	visitors map[string]*visitor
+	limit    rate.Limit
+	burst    int
+	ttl      time.Duration
+}
+
+// NewIPRateLimiter create the limiter and start sweeping idle visitors until ctx is done, a ttl
+// that is not positive falls back to defaultVisitorTTL
+func NewIPRateLimiter(ctx context.Context, limit rate.Limit, burst int, ttl time.Duration) *IPRateLimiter {
+	l := &IPRateLimiter{
+		visitors: map[string]*visitor{},
+		limit:    limit,
+		burst:    burst,
+		ttl:      visitorTTL(ttl),
+	}
+
+	go l.sweep(ctx)
+
+	return l
+}
+
+// Reserve take a token for key, returning how long the caller has to wait when none is available
+func (l *IPRateLimiter) Reserve(_ context.Context, key string) (time.Duration, error) {
+	l.mu.Lock()
+	v, ok := l.visitors[key]
+	if !ok {
+		v = &visitor{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.visitors[key] = v
+	}
+	v.lastSeen = time.Now()
+	l.mu.Unlock()
+
+	reservation := v.limiter.Reserve()
+	delay := reservation.Delay()
+	if delay > 0 {
+		reservation.Cancel()
+	}
+
+	return delay, nil
+}
+
+func (l *IPRateLimiter) sweep(ctx context.Context) {
+	ticker := time.NewTicker(l.ttl)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			l.mu.Lock()
+			for key, v := range l.visitors {
+				if now.Sub(v.lastSeen) > l.ttl {
+					delete(l.visitors, key)
+				}
+			}
+			l.mu.Unlock()
+		}
+	}
+}
+
+// rateLimitMiddleware reject requests over the limit with 429 and a Retry-After header. An
+// authenticated merchant is limited as a whole, anonymous callers per client ip. When the
+// limiter itself fails the request is let through rather than failing the api with it.
+func rateLimitMiddleware(limiter rateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		delay, err := limiter.Reserve(c.Request.Context(), rateLimitKey(c))
+		if err != nil {
+			logging.FromContext(c.Request.Context()).WarnContext(c.Request.Context(), "rate limiter unavailable, request allowed", "error", err)
+			c.Next()
+			return
+		}
+
+		if delay > 0 {
+			abortRateLimited(c, delay)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// abortRateLimited answer 429 with a Retry-After of delay rounded up to whole seconds
+func abortRateLimited(c *gin.Context, delay time.Duration) {
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+	abortWithError(c, http.StatusTooManyRequests, apperror.CodeRateLimited, "too many requests")
+}
+
+// rateLimitKey identify the caller, the merchant set by apiKeyAuth or else the client ip
+func rateLimitKey(c *gin.Context) string {
+	if merchantId, ok := auth.MerchantFromContext(c.Request.Context()); ok {
+		return "merchant:" + merchantId
+	}
+	return "ip:" + c.ClientIP()
+}
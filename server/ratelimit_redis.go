@@ -0,0 +1,63 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/time/rate"
+)
+
+// tokenBucketScript refill and take from a token bucket stored as a hash, atomically on the
+// redis side and by the redis clock so every instance agrees. It returns the milliseconds to
+// wait, 0 when a token was taken.
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local ttl = tonumber(ARGV[3])
+
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(bucket[1]) or burst
+local ts = tonumber(bucket[2]) or now
+
+tokens = math.min(burst, tokens + (now - ts) * rate / 1000)
+
+local wait = 0
+if tokens < 1 then
+	wait = math.ceil((1 - tokens) * 1000 / rate)
+else
+	tokens = tokens - 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], ttl)
+return wait
+`)
+
+// RedisRateLimiter keep the token buckets in redis so every instance behind the load balancer
+// shares the same limit. Idle buckets expire after ttl.
+type RedisRateLimiter struct {
+	client *redis.Client
+	limit  rate.Limit
+	burst  int
+	ttl    time.Duration
+}
+
+// NewRedisRateLimiter create a limiter on client with the same semantics as IPRateLimiter, a
+// ttl that is not positive falls back to defaultVisitorTTL as well
+func NewRedisRateLimiter(client *redis.Client, limit rate.Limit, burst int, ttl time.Duration) *RedisRateLimiter {
+	return &RedisRateLimiter{client: client, limit: limit, burst: burst, ttl: visitorTTL(ttl)}
+}
+
+// Reserve take a token for key, returning how long the caller has to wait when none is available
+func (l *RedisRateLimiter) Reserve(ctx context.Context, key string) (time.Duration, error) {
+	wait, err := tokenBucketScript.Run(ctx, l.client, []string{"ratelimit:" + key}, float64(l.limit), l.burst, l.ttl.Milliseconds()).Int64()
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(wait) * time.Millisecond, nil
+}
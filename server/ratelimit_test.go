@@ -0,0 +1,37 @@
+package server
+
+import (
+	"net/http/httptest"
+	"test-sql/auth"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimitKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		merchantId string
+		remoteAddr string
+		want       string
+	}{
+		{name: "anonymous by ip", remoteAddr: "203.0.113.7:51234", want: "ip:203.0.113.7"},
+		{name: "merchant wins over ip", merchantId: "M001", remoteAddr: "203.0.113.7:51234", want: "merchant:M001"},
+		{name: "empty merchant is anonymous", merchantId: "", remoteAddr: "198.51.100.1:80", want: "ip:198.51.100.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("POST", "/graphql", nil)
+			c.Request.RemoteAddr = tt.remoteAddr
+			if tt.merchantId != "" {
+				c.Request = c.Request.WithContext(auth.WithMerchant(c.Request.Context(), tt.merchantId))
+			}
+
+			if got := rateLimitKey(c); got != tt.want {
+				t.Errorf("rateLimitKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
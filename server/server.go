@@ -0,0 +1,379 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"test-sql/apperror"
+	"test-sql/auth"
+	"test-sql/db"
+	"test-sql/dotenv"
+	"test-sql/graph"
+	"test-sql/version"
+	"time"
+
+	helmet "github.com/danielkov/gin-helmet"
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/time/rate"
+)
+
+const graphiqlPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="utf-8" />
+	<title>GraphiQL</title>
+	<style>body { height: 100%; margin: 0; width: 100%; overflow: hidden; } #graphiql { height: 100vh; }</style>
+	<link rel="stylesheet" href="https://unpkg.com/graphiql@3/graphiql.min.css" />
+</head>
+<body>
+	<div id="graphiql">Loading...</div>
+	<script crossorigin src="https://unpkg.com/react@18/umd/react.production.min.js"></script>
+	<script crossorigin src="https://unpkg.com/react-dom@18/umd/react-dom.production.min.js"></script>
+	<script crossorigin src="https://unpkg.com/graphiql@3/graphiql.min.js"></script>
+	<script>
+		const fetcher = GraphiQL.createFetcher({ url: '/graphql' });
+		const root = ReactDOM.createRoot(document.getElementById('graphiql'));
+		root.render(React.createElement(GraphiQL, { fetcher: fetcher }));
+	</script>
+</body>
+</html>`
+
+// NewRouter wire the middlewares, health probes and graphql endpoints. The context bounds
+// background work such as the rate limiter sweep, the breaker state is reported by /health.
+// The returned func releases what the router holds open, call it once the server is shut down.
+func NewRouter(ctx context.Context, executor *graph.Executor, conn *sql.DB, breaker *db.Breaker) (*gin.Engine, func() error, error) {
+	// setup router
+	router := gin.New()
+
+	// only the proxies listed in TRUSTED_PROXIES (comma separated ips or cidrs) may set the
+	// client ip through X-Forwarded-For, none by default so ClientIP is the peer address
+	if err := router.SetTrustedProxies(trustedProxies()); err != nil {
+		return nil, nil, err
+	}
+	router.Use(requestLogger(), recovery())
+
+	// Set a lower memory limit for multipart forms (default is 32 MiB)
+	router.MaxMultipartMemory = 10 << 20 // 10 MiB
+
+	// Setup Mode Application
+	if os.Getenv("APP_ENV") == "production" {
+		gin.SetMode(gin.ReleaseMode)
+	} else {
+		gin.SetMode(gin.DebugMode)
+	}
+
+	// health probes, registered before the global middlewares so they skip cors/helmet/gzip
+	// liveness never touches the database, it only reports the breaker state and cache counters
+	router.GET("/health", func(c *gin.Context) {
+		health := gin.H{"status": "ok", "database": breaker.State()}
+		if stats := executor.CacheStats(); stats != nil {
+			health["responseCache"] = stats
+		}
+		c.JSON(http.StatusOK, health)
+	})
+
+	// build metadata, served in every environment to check which build is deployed
+	router.GET("/version", func(c *gin.Context) {
+		c.JSON(http.StatusOK, version.Get())
+	})
+
+	// readiness also fails while the pool is saturated, READY_POOL_SATURATION percent (default
+	// 100) of the open connections in use and more than READY_POOL_MAX_WAITS (default 0) new
+	// waits for a connection since the previous probe
+	pool := newPoolWatcher(conn, dotenv.GetInt("READY_POOL_SATURATION", 100), dotenv.GetInt("READY_POOL_MAX_WAITS", 0))
+	router.GET("/ready", func(c *gin.Context) {
+		// checked before the ping, which would itself queue on a saturated pool
+		if stats, newWaits, saturated := pool.check(); saturated {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"status":   "unavailable",
+				"error":    "connection pool saturated",
+				"inUse":    stats.InUse,
+				"maxOpen":  stats.MaxOpenConnections,
+				"newWaits": newWaits,
+			})
+			return
+		}
+
+		pingCtx, cancel := context.WithTimeout(c.Request.Context(), time.Duration(dotenv.GetInt("READY_TIMEOUT", 2))*time.Second)
+		defer cancel()
+
+		if err := conn.PingContext(pingCtx); err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+
+	// profiling is opt in, also before the global middlewares so gzip and rate limit stay out
+	if dotenv.GetBool("PPROF_ENABLED", false) {
+		registerPprof(router)
+	}
+
+	// setup cors origin
+	router.Use(cors.New(corsConfig()))
+	router.Use(helmet.Default())
+
+	// compress responses from GZIP_MIN_LENGTH bytes (default 1024) at GZIP_LEVEL, 1 fastest to
+	// 9 smallest, -1 the zlib default (6). 0 turns compression off.
+	if level := dotenv.GetInt("GZIP_LEVEL", gzip.DefaultCompression); level != gzip.NoCompression {
+		if level < gzip.HuffmanOnly || level > gzip.BestCompression {
+			level = gzip.DefaultCompression
+		}
+		router.Use(gzipMiddleware(level, dotenv.GetInt("GZIP_MIN_LENGTH", 1024)))
+	}
+
+	// rate limit per merchant or client ip, disabled when RATE_LIMIT_RPS is 0. With REDIS_URL
+	// the buckets are shared by every instance, otherwise each instance counts on its own.
+	closeRouter := func() error { return nil }
+	var limiter rateLimiter
+	if rps := dotenv.GetInt("RATE_LIMIT_RPS", 10); rps > 0 {
+		burst := dotenv.GetInt("RATE_LIMIT_BURST", 20)
+		ttl := time.Duration(dotenv.GetInt("RATE_LIMIT_TTL", 180)) * time.Second
+
+		if redisURL := os.Getenv("REDIS_URL"); redisURL != "" {
+			options, err := redis.ParseURL(redisURL)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid REDIS_URL: %w", err)
+			}
+
+			client := redis.NewClient(options)
+			closeRouter = client.Close
+			limiter = NewRedisRateLimiter(client, rate.Limit(rps), burst, ttl)
+		} else {
+			limiter = NewIPRateLimiter(ctx, rate.Limit(rps), burst, ttl)
+		}
+	}
+
+	// static api keys from API_KEYS (key:merchantId,...), only wired when some are configured.
+	// A rejected key spends a token of the client ip so keys can't be guessed unthrottled.
+	if keys := auth.ParseAPIKeys(os.Getenv("API_KEYS")); keys.Len() > 0 {
+		router.Use(apiKeyAuth(keys, limiter))
+	}
+
+	if limiter != nil {
+		router.Use(rateLimitMiddleware(limiter))
+	}
+
+	// bound every graphql request by REQUEST_TIMEOUT seconds (default 15)
+	timeout := requestTimeout(time.Duration(dotenv.GetInt("REQUEST_TIMEOUT", 15)) * time.Second)
+
+	router.POST("/graphql", tracingMiddleware(), timeout, localeMiddleware(), func(c *gin.Context) {
+		// json is assumed when no content type is sent, as before
+		contentType := c.ContentType()
+		if contentType != "" && contentType != "application/json" && contentType != "application/graphql" {
+			abortWithError(c, http.StatusUnsupportedMediaType, apperror.CodeUnsupportedMedia, fmt.Sprintf("unsupported content type %q, use application/json or application/graphql", contentType))
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, int64(dotenv.GetInt("GRAPHQL_MAX_BODY_BYTES", 1<<20)))
+
+		body, err := c.GetRawData()
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				abortWithError(c, http.StatusRequestEntityTooLarge, apperror.CodePayloadTooLarge, fmt.Sprintf("request body exceeds %d bytes", maxBytesErr.Limit))
+				return
+			}
+
+			abortWithError(c, http.StatusBadRequest, apperror.CodeBadRequest, err.Error())
+			return
+		}
+
+		// application/graphql carries the bare query, the operation name may come in the url
+		if contentType == "application/graphql" {
+			params := graph.Request{
+				Query:         string(body),
+				OperationName: c.Query("operationName"),
+			}
+
+			result := executor.Execute(c.Request.Context(), params)
+			if requestTimedOut(c) {
+				return
+			}
+
+			c.JSON(resultStatus(result), result)
+			return
+		}
+
+		// batched operations are sent as a json array
+		if isBatchRequest(body) {
+			var batch []graph.Request
+			if err := json.Unmarshal(body, &batch); err != nil {
+				abortWithError(c, http.StatusBadRequest, apperror.CodeBadRequest, jsonErrorMessage(err))
+				return
+			}
+
+			maxBatch := dotenv.GetInt("GRAPHQL_MAX_BATCH", 20)
+			if len(batch) > maxBatch {
+				abortWithError(c, http.StatusBadRequest, apperror.CodeBadRequest, fmt.Sprintf("batch size %d exceeds the maximum of %d", len(batch), maxBatch))
+				return
+			}
+
+			results := make([]*graphql.Result, len(batch))
+			for i, params := range batch {
+				results[i] = executor.Execute(c.Request.Context(), params)
+			}
+
+			if requestTimedOut(c) {
+				return
+			}
+
+			c.JSON(http.StatusOK, results)
+			return
+		}
+
+		var params graph.Request
+		if err := json.Unmarshal(body, &params); err != nil {
+			abortWithError(c, http.StatusBadRequest, apperror.CodeBadRequest, jsonErrorMessage(err))
+			return
+		}
+
+		result := executor.Execute(c.Request.Context(), params)
+		if requestTimedOut(c) {
+			return
+		}
+
+		c.JSON(resultStatus(result), result)
+	})
+
+	// graphql over get for cacheable queries, without a query it serves the graphiql
+	// explorer for non production environment
+	router.GET("/graphql", tracingMiddleware(), timeout, localeMiddleware(), func(c *gin.Context) {
+		params := graph.Request{
+			Query:         c.Query("query"),
+			OperationName: c.Query("operationName"),
+		}
+
+		if params.Query == "" {
+			if os.Getenv("APP_ENV") != "production" {
+				c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(graphiqlPage))
+				return
+			}
+
+			abortWithError(c, http.StatusBadRequest, apperror.CodeBadRequest, "query parameter is required")
+			return
+		}
+
+		if variables := c.Query("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &params.Variables); err != nil {
+				abortWithError(c, http.StatusBadRequest, apperror.CodeBadRequest, "variables: "+jsonErrorMessage(err))
+				return
+			}
+		}
+
+		if graph.IsMutation(params.Query, params.OperationName) {
+			c.Header("Allow", http.MethodPost)
+			abortWithError(c, http.StatusMethodNotAllowed, apperror.CodeMethodNotAllowed, "mutations are only allowed over POST")
+			return
+		}
+
+		result := executor.Execute(c.Request.Context(), params)
+		if requestTimedOut(c) {
+			return
+		}
+
+		// conditional responses are opt in with ?etag=true so existing clients see no change
+		if c.Query("etag") == "true" {
+			writeResultWithETag(c, result)
+			return
+		}
+
+		c.JSON(resultStatus(result), result)
+	})
+
+	return router, closeRouter, nil
+}
+
+// trustedProxies read TRUSTED_PROXIES, nil when unset which trusts no proxy
+func trustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
+// isBatchRequest report whether the body is a json array of operations
+func isBatchRequest(body []byte) bool {
+	trimmed := bytes.TrimLeft(body, " \t\r\n")
+	return len(trimmed) > 0 && trimmed[0] == '['
+}
+
+// clientErrorCodes codes of errors caused by the request itself, rejected while parsing or
+// validating it or by an input check
+var clientErrorCodes = map[string]bool{
+	apperror.CodeParseFailed:      true,
+	apperror.CodeValidationFailed: true,
+	apperror.CodeValidation:       true,
+	apperror.CodeReadOnly:         true,
+}
+
+// resultStatus pick the http status for a graphql result, 400 when every error is a client
+// error and 500 otherwise. The decision is made from the error codes, not from Data, since a
+// failing non null root field nulls Data too. GRAPHQL_ALWAYS_OK keeps the legacy always 200
+// behavior.
+func resultStatus(result *graphql.Result) int {
+	if !result.HasErrors() || dotenv.GetBool("GRAPHQL_ALWAYS_OK", false) {
+		return http.StatusOK
+	}
+
+	for _, err := range result.Errors {
+		if code, _ := err.Extensions["code"].(string); !clientErrorCodes[code] {
+			return http.StatusInternalServerError
+		}
+	}
+
+	return http.StatusBadRequest
+}
+
+// corsConfig build the cors policy from the comma separated CORS_ORIGINS env. Credentials are
+// only allowed for an explicit origin list since browsers reject them together with "*".
+func corsConfig() cors.Config {
+	defaultOrigins := "http://localhost:3000,http://localhost:8080,http://127.0.0.1:3000"
+	if os.Getenv("APP_ENV") == "production" {
+		defaultOrigins = "*"
+	}
+
+	var origins []string
+	allowAll := false
+	for _, origin := range strings.Split(dotenv.GetString("CORS_ORIGINS", defaultOrigins), ",") {
+		origin = strings.TrimSpace(origin)
+		if origin == "" {
+			continue
+		}
+		if origin == "*" {
+			allowAll = true
+		}
+		origins = append(origins, origin)
+	}
+
+	config := cors.Config{
+		AllowMethods:  []string{http.MethodGet, http.MethodPost, http.MethodOptions},
+		AllowHeaders:  []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Request-ID"},
+		ExposeHeaders: []string{"Content-Length", "X-Request-ID"},
+	}
+
+	if allowAll || len(origins) == 0 {
+		config.AllowAllOrigins = true
+		return config
+	}
+
+	config.AllowOrigins = origins
+	config.AllowCredentials = true
+	config.AllowWildcard = true
+
+	return config
+}
@@ -0,0 +1,281 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"test-sql/apperror"
+	"test-sql/db"
+	"test-sql/graph"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+)
+
+// coded build a graphql error carrying code in its extensions, no code when empty
+func coded(code string) gqlerrors.FormattedError {
+	err := gqlerrors.FormattedError{Message: "failed"}
+	if code != "" {
+		err.Extensions = map[string]interface{}{"code": code}
+	}
+	return err
+}
+
+func TestResultStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   *graphql.Result
+		alwaysOk string
+		want     int
+	}{
+		{
+			name:   "no errors",
+			result: &graphql.Result{Data: map[string]interface{}{"locale": "en"}},
+			want:   http.StatusOK,
+		},
+		{
+			name:   "parse failure",
+			result: &graphql.Result{Errors: []gqlerrors.FormattedError{coded(apperror.CodeParseFailed)}},
+			want:   http.StatusBadRequest,
+		},
+		{
+			name:   "validation failure",
+			result: &graphql.Result{Errors: []gqlerrors.FormattedError{coded(apperror.CodeValidationFailed), coded(apperror.CodeValidation)}},
+			want:   http.StatusBadRequest,
+		},
+		{
+			name:   "non null root field failed without data",
+			result: &graphql.Result{Errors: []gqlerrors.FormattedError{coded(apperror.CodeInternal)}},
+			want:   http.StatusInternalServerError,
+		},
+		{
+			name:   "resolver failure with partial data",
+			result: &graphql.Result{Data: map[string]interface{}{"product": nil}, Errors: []gqlerrors.FormattedError{coded(apperror.CodeTimeout)}},
+			want:   http.StatusInternalServerError,
+		},
+		{
+			name:   "client and server errors mixed",
+			result: &graphql.Result{Errors: []gqlerrors.FormattedError{coded(apperror.CodeValidation), coded("")}},
+			want:   http.StatusInternalServerError,
+		},
+		{
+			name:     "always ok",
+			result:   &graphql.Result{Errors: []gqlerrors.FormattedError{coded(apperror.CodeInternal)}},
+			alwaysOk: "true",
+			want:     http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GRAPHQL_ALWAYS_OK", tt.alwaysOk)
+
+			if got := resultStatus(tt.result); got != tt.want {
+				t.Errorf("resultStatus() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// newTestRouter build the router over conn with an executor whose repositories are never
+// reached by the request under test
+func newTestRouter(t *testing.T, conn *sql.DB) *gin.Engine {
+	t.Helper()
+
+	executor, err := graph.NewExecutor(nil, nil)
+	if err != nil {
+		t.Fatalf("NewExecutor() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	router, closeRouter, err := NewRouter(ctx, executor, conn, db.NewBreaker(5, time.Second))
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+	t.Cleanup(func() { closeRouter() })
+	return router
+}
+
+func TestHealthAndReady(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		closed     bool
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "health with a live database", path: "/health", wantStatus: http.StatusOK, wantBody: `"status":"ok"`},
+		{name: "health with a closed database", path: "/health", closed: true, wantStatus: http.StatusOK, wantBody: `"status":"ok"`},
+		{name: "ready with a live database", path: "/ready", wantStatus: http.StatusOK, wantBody: `"status":"ok"`},
+		{name: "ready with a closed database", path: "/ready", closed: true, wantStatus: http.StatusServiceUnavailable, wantBody: `"status":"unavailable"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+			if err != nil {
+				t.Fatalf("sqlmock.New() error = %v", err)
+			}
+			if tt.closed {
+				mock.ExpectClose()
+				conn.Close()
+			} else {
+				if tt.path == "/ready" {
+					mock.ExpectPing()
+				}
+				defer conn.Close()
+			}
+
+			router := newTestRouter(t, conn)
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			if !strings.Contains(recorder.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %s", recorder.Body.String(), tt.wantBody)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestCorsHeaders(t *testing.T) {
+	tests := []struct {
+		name            string
+		appEnv          string
+		origins         string
+		origin          string
+		wantOrigin      string
+		wantCredentials string
+	}{
+		{name: "dev default allows localhost with credentials", origin: "http://localhost:3000", wantOrigin: "http://localhost:3000", wantCredentials: "true"},
+		{name: "dev default rejects other origins", origin: "https://evil.example"},
+		{name: "production default allows any origin without credentials", appEnv: "production", origin: "https://shop.example", wantOrigin: "*"},
+		{name: "listed origin with credentials", origins: "https://shop.example, https://admin.example", origin: "https://admin.example", wantOrigin: "https://admin.example", wantCredentials: "true"},
+		{name: "wildcard in the list drops credentials", origins: "https://shop.example,*", origin: "https://other.example", wantOrigin: "*"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("APP_ENV", tt.appEnv)
+			t.Setenv("CORS_ORIGINS", tt.origins)
+
+			router := gin.New()
+			router.Use(cors.New(corsConfig()))
+			router.POST("/graphql", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			request := httptest.NewRequest(http.MethodOptions, "/graphql", nil)
+			request.Header.Set("Origin", tt.origin)
+			request.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			allowOrigin := recorder.Header().Get("Access-Control-Allow-Origin")
+			allowCredentials := recorder.Header().Get("Access-Control-Allow-Credentials")
+			if allowOrigin != tt.wantOrigin || allowCredentials != tt.wantCredentials {
+				t.Errorf("allow origin %q credentials %q, want %q and %q", allowOrigin, allowCredentials, tt.wantOrigin, tt.wantCredentials)
+			}
+
+			// browsers reject credentials together with a wildcard origin
+			if allowOrigin == "*" && allowCredentials == "true" {
+				t.Error("credentials allowed for the * origin")
+			}
+		})
+	}
+}
+
+func TestGraphqlContentTypes(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		url         string
+		body        string
+		wantStatus  int
+		wantBody    string
+	}{
+		{name: "json", contentType: "application/json", body: `{"query":"{ locale }"}`, wantStatus: http.StatusOK, wantBody: `"locale":"`},
+		{name: "json with a charset", contentType: "application/json; charset=utf-8", body: `{"query":"{ locale }"}`, wantStatus: http.StatusOK, wantBody: `"locale":"`},
+		{name: "no content type is json", body: `{"query":"{ locale }"}`, wantStatus: http.StatusOK, wantBody: `"locale":"`},
+		{name: "graphql", contentType: "application/graphql", body: `{ locale }`, wantStatus: http.StatusOK, wantBody: `"locale":"`},
+		{
+			name:        "graphql with the operation name in the url",
+			contentType: "application/graphql",
+			url:         "/graphql?operationName=Kind",
+			body:        `query Locale { locale } query Kind { __typename }`,
+			wantStatus:  http.StatusOK,
+			wantBody:    `"__typename":"RootQuery"`,
+		},
+		{name: "graphql syntax error", contentType: "application/graphql", body: `{ locale`, wantStatus: http.StatusBadRequest, wantBody: apperror.CodeParseFailed},
+		{name: "plain text", contentType: "text/plain", body: `{ locale }`, wantStatus: http.StatusUnsupportedMediaType, wantBody: apperror.CodeUnsupportedMedia},
+		{name: "form", contentType: "application/x-www-form-urlencoded", body: `query=%7B+locale+%7D`, wantStatus: http.StatusUnsupportedMediaType, wantBody: apperror.CodeUnsupportedMedia},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, _, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("sqlmock.New() error = %v", err)
+			}
+			defer conn.Close()
+
+			url := tt.url
+			if url == "" {
+				url = "/graphql"
+			}
+			request := httptest.NewRequest(http.MethodPost, url, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				request.Header.Set("Content-Type", tt.contentType)
+			}
+			recorder := httptest.NewRecorder()
+			newTestRouter(t, conn).ServeHTTP(recorder, request)
+
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			if !strings.Contains(recorder.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %s", recorder.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestCloseRouterClosesRedis(t *testing.T) {
+	t.Setenv("RATE_LIMIT_RPS", "10")
+	t.Setenv("REDIS_URL", "redis://127.0.0.1:6379/0")
+
+	executor, err := graph.NewExecutor(nil, nil)
+	if err != nil {
+		t.Fatalf("NewExecutor() error = %v", err)
+	}
+	conn, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New() error = %v", err)
+	}
+	defer conn.Close()
+
+	_, closeRouter, err := NewRouter(context.Background(), executor, conn, db.NewBreaker(5, time.Second))
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+
+	if err := closeRouter(); err != nil {
+		t.Fatalf("closeRouter() error = %v", err)
+	}
+	// closing the redis client twice fails, so a second error proves the first call closed it
+	if err := closeRouter(); err == nil {
+		t.Error("second closeRouter() succeeded, want the redis client already closed")
+	}
+}
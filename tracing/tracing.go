@@ -0,0 +1,53 @@
+package tracing
+
+import (
+	"context"
+	"test-sql/dotenv"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracer shared by the http and db spans
+var Tracer = otel.Tracer("test-sql")
+
+// Init export spans over OTLP when OTEL_EXPORTER_OTLP_ENDPOINT is set, otherwise the
+// global no-op provider stays in place. The returned func flushes pending spans on shutdown.
+func Init(ctx context.Context) (func(context.Context) error, error) {
+	if dotenv.GetString("OTEL_EXPORTER_OTLP_ENDPOINT", "") == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	// the exporter reads the endpoint and headers from the standard OTEL_EXPORTER_OTLP_* env
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceName(dotenv.GetString("OTEL_SERVICE_NAME", "learn-graphql")),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// StartSpan start a child span for a db call
+func StartSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return Tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemMySQL,
+			attribute.String("db.statement.name", name),
+		),
+	)
+}
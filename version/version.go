@@ -0,0 +1,46 @@
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// build metadata injected at build time, e.g.
+//
+//	go build -ldflags "-X test-sql/version.Commit=$(git rev-parse HEAD) -X test-sql/version.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	Commit    = ""
+	BuildTime = ""
+)
+
+// Info build metadata of the running binary
+type Info struct {
+	Commit    string `json:"commit"`
+	BuildTime string `json:"buildTime"`
+	GoVersion string `json:"goVersion"`
+}
+
+// Get return the build metadata, the commit and time recorded by the go toolchain are used when
+// nothing was injected and "unknown" when neither is available
+func Get() Info {
+	info := Info{Commit: Commit, BuildTime: BuildTime, GoVersion: runtime.Version()}
+
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = setting.Value
+			case setting.Key == "vcs.time" && info.BuildTime == "":
+				info.BuildTime = setting.Value
+			}
+		}
+	}
+
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.BuildTime == "" {
+		info.BuildTime = "unknown"
+	}
+	return info
+}
@@ -0,0 +1,38 @@
+package version
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestGet(t *testing.T) {
+	tests := []struct {
+		name      string
+		commit    string
+		buildTime string
+	}{
+		{name: "injected", commit: "abc123", buildTime: "2026-01-02T03:04:05Z"},
+		{name: "commit only", commit: "def456"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Commit, BuildTime = tt.commit, tt.buildTime
+			t.Cleanup(func() { Commit, BuildTime = "", "" })
+
+			info := Get()
+			if info.Commit != tt.commit {
+				t.Errorf("Commit = %q, want %q", info.Commit, tt.commit)
+			}
+			if tt.buildTime != "" && info.BuildTime != tt.buildTime {
+				t.Errorf("BuildTime = %q, want %q", info.BuildTime, tt.buildTime)
+			}
+			if info.BuildTime == "" {
+				t.Error("BuildTime is empty, want a value or \"unknown\"")
+			}
+			if info.GoVersion != runtime.Version() {
+				t.Errorf("GoVersion = %q, want %q", info.GoVersion, runtime.Version())
+			}
+		})
+	}
+}